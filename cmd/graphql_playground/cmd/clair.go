@@ -0,0 +1,210 @@
+//
+// Copyright 2023 The GUAC Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Khan/genqlient/graphql"
+	model "github.com/guacsec/guac/pkg/assembler/clients/generated"
+	"github.com/guacsec/guac/pkg/handler/collector/clair"
+	"github.com/guacsec/guac/pkg/logging"
+	"github.com/package-url/packageurl-go"
+)
+
+// ingestVulnerabilities scans a demo package set through the clair
+// enrichment subsystem, ingests each finding as a CertifyVuln plus its
+// VulnerabilityMetadata, and returns the findings annotated with the
+// CertifyVuln ID each was ingested under so a later sweep can supersede
+// them.
+func ingestVulnerabilities(ctx context.Context, client graphql.Client) []clair.VulnFinding {
+	logger := logging.FromContext(ctx)
+
+	config := clair.Config{
+		IndexerURL: "http://localhost:8081",
+		MatcherURL: "http://localhost:8081",
+	}
+
+	packages, err := allGraphPackages(ctx, client)
+	if err != nil {
+		logger.Errorf("Error in querying packages to scan: %v\n", err)
+		return nil
+	}
+
+	findings, err := clair.EnrichPackages(ctx, config, packages)
+	if err != nil {
+		logger.Errorf("Error in enriching packages with clair: %v\n", err)
+		return nil
+	}
+
+	for i, finding := range findings {
+		pkg, err := packageInputSpecFromPurl(finding.Package.Purl)
+		if err != nil {
+			logger.Errorf("Error deriving package for %q: %v\n", finding.Package.Purl, err)
+			continue
+		}
+
+		vuln := model.VulnerabilityInputSpec{
+			Type:            vulnerabilityType(finding.VulnerabilityID),
+			VulnerabilityID: finding.VulnerabilityID,
+		}
+
+		certifyVuln := model.CertifyVulnInputSpec{
+			TimeScanned:    finding.TimeScanned,
+			DbUri:          "clair",
+			DbVersion:      "4",
+			ScannerUri:     "clair",
+			ScannerVersion: "4",
+			Origin:         finding.Origin,
+			Collector:      "clair",
+		}
+
+		resp, err := model.CertifyVuln(ctx, client, pkg, vuln, certifyVuln)
+		if err != nil {
+			logger.Errorf("Error in ingesting: %v\n", err)
+			continue
+		}
+		fmt.Printf("Response is |%v|\n", resp)
+		findings[i].CertifyVulnID = resp.IngestVulnerability
+
+		metadata := model.VulnerabilityMetadataInputSpec{
+			ScoreType:  "cvss",
+			ScoreValue: finding.CVSSScore,
+			Timestamp:  finding.TimeScanned,
+			Origin:     fmt.Sprintf("%s (severity: %s)", finding.Origin, finding.Severity),
+			Collector:  "clair",
+		}
+		metaResp, err := model.VulnerabilityMetadata(ctx, client, vuln, metadata)
+		if err != nil {
+			logger.Errorf("Error in ingesting: %v\n", err)
+			continue
+		}
+		fmt.Printf("Response is |%v|\n", metaResp)
+	}
+
+	return findings
+}
+
+// vulnerabilityType derives the VulnerabilityInputSpec.Type guac expects
+// ("ghsa" or "cve") from a normalized vulnerability id's prefix, since Clair
+// reports GHSA ids for language-ecosystem packages alongside CVE ids for OS
+// packages and the two must not collide under the same vulnerability type.
+func vulnerabilityType(vulnerabilityID string) string {
+	if strings.HasPrefix(vulnerabilityID, "GHSA-") {
+		return "ghsa"
+	}
+	return "cve"
+}
+
+// sweepVulnerabilities re-scans the same package set and ingests a "novuln"
+// CertifyVuln for every finding from a previous scan that the new one no
+// longer reports, marking it resolved per the backend's novuln convention
+// rather than deleting the original edge.
+func sweepVulnerabilities(ctx context.Context, client graphql.Client, previous []clair.VulnFinding) {
+	logger := logging.FromContext(ctx)
+
+	config := clair.Config{
+		IndexerURL: "http://localhost:8081",
+		MatcherURL: "http://localhost:8081",
+	}
+
+	packages, err := allGraphPackages(ctx, client)
+	if err != nil {
+		logger.Errorf("Error in querying packages to scan: %v\n", err)
+		return
+	}
+
+	current, err := clair.EnrichPackages(ctx, config, packages)
+	if err != nil {
+		logger.Errorf("Error in enriching packages with clair: %v\n", err)
+		return
+	}
+
+	for _, resolved := range clair.SweepResolved(previous, current) {
+		pkg, err := packageInputSpecFromPurl(resolved.Package.Purl)
+		if err != nil {
+			logger.Errorf("Error deriving package for %q: %v\n", resolved.Package.Purl, err)
+			continue
+		}
+
+		novuln := model.VulnerabilityInputSpec{Type: "novuln"}
+		certifyVuln := model.CertifyVulnInputSpec{
+			TimeScanned:    time.Now(),
+			DbUri:          "clair",
+			DbVersion:      "4",
+			ScannerUri:     "clair",
+			ScannerVersion: "4",
+			Origin:         fmt.Sprintf("clair sweep: supersedes %s", resolved.CertifyVulnID),
+			Collector:      "clair",
+		}
+
+		resp, err := model.CertifyVuln(ctx, client, pkg, novuln, certifyVuln)
+		if err != nil {
+			logger.Errorf("Error in ingesting: %v\n", err)
+			continue
+		}
+		fmt.Printf("Response is |%v|\n", resp)
+	}
+}
+
+// allGraphPackages queries every Package node reachable in the graph and
+// flattens the type/namespace/name/version tree the backend returns into the
+// PackageNode purls clair.EnrichPackages scans, so a clair sweep covers the
+// whole graph rather than whatever slice a caller happens to hand it.
+func allGraphPackages(ctx context.Context, client graphql.Client) ([]clair.PackageNode, error) {
+	resp, err := model.Packages(ctx, client, model.PkgSpec{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query packages: %w", err)
+	}
+
+	var packages []clair.PackageNode
+	for _, pkg := range resp.Packages {
+		for _, ns := range pkg.Namespaces {
+			for _, name := range ns.Names {
+				for _, version := range name.Versions {
+					packages = append(packages, clair.PackageNode{
+						ID:   version.Id,
+						Purl: version.Purl,
+					})
+				}
+			}
+		}
+	}
+
+	return packages, nil
+}
+
+// packageInputSpecFromPurl derives a model.PkgInputSpec from a purl, mirroring
+// the purl-to-identity derivation clair.manifestFromPurl does internally for
+// the indexer request.
+func packageInputSpecFromPurl(purl string) (model.PkgInputSpec, error) {
+	instance, err := packageurl.FromString(purl)
+	if err != nil {
+		return model.PkgInputSpec{}, fmt.Errorf("failed to parse purl %q: %w", purl, err)
+	}
+
+	namespace := instance.Namespace
+	version := instance.Version
+	return model.PkgInputSpec{
+		Type:      instance.Type,
+		Namespace: &namespace,
+		Name:      instance.Name,
+		Version:   &version,
+	}, nil
+}
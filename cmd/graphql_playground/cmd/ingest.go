@@ -23,6 +23,7 @@ import (
 
 	"github.com/Khan/genqlient/graphql"
 	model "github.com/guacsec/guac/pkg/assembler/clients/generated"
+	"github.com/guacsec/guac/pkg/handler/collector/sigstore"
 	"github.com/guacsec/guac/pkg/logging"
 )
 
@@ -42,6 +43,10 @@ func ingestData(port int) {
 	ingestScorecards(ctx, gqlclient)
 	ingestDependency(ctx, gqlclient)
 	ingestOccurrence(ctx, gqlclient)
+	ingestAttestations(ctx, gqlclient)
+	ingestGitSource(ctx, gqlclient)
+	findings := ingestVulnerabilities(ctx, gqlclient)
+	sweepVulnerabilities(ctx, gqlclient, findings)
 	logger.Infof("Finished ingesting test data into backend server")
 }
 
@@ -70,7 +75,7 @@ func ingestScorecards(ctx context.Context, client graphql.Client) {
 		Origin:           "Demo ingestion",
 		Collector:        "Demo ingestion",
 	}
-	resp, err := model.Scorecard(context.Background(), client, source, scorecard)
+	resp, err := model.Scorecard(ctx, client, source, scorecard)
 	if err != nil {
 		// TODO(mihaimaruseac): Panic or just error and continue?
 		logger.Errorf("Error in ingesting: %v\n", err)
@@ -131,7 +136,7 @@ func ingestDependency(ctx context.Context, client graphql.Client) {
 		},
 	}}
 	for _, ingest := range ingestDependencies {
-		resp, err := model.IsDependency(context.Background(), client, ingest.pkg, ingest.depPkg, ingest.dependency)
+		resp, err := model.IsDependency(ctx, client, ingest.pkg, ingest.depPkg, ingest.dependency)
 		if err != nil {
 			logger.Errorf("Error in ingesting: %v\n", err)
 		}
@@ -202,13 +207,13 @@ func ingestOccurrence(ctx context.Context, client graphql.Client) {
 	}}
 	for _, ingest := range ingestOccurrences {
 		if ingest.pkg != nil {
-			respPkg, err := model.IsOccurrencePkg(context.Background(), client, ingest.pkg, ingest.art, ingest.occurrence)
+			respPkg, err := model.IsOccurrencePkg(ctx, client, ingest.pkg, ingest.art, ingest.occurrence)
 			if err != nil {
 				logger.Errorf("Error in ingesting: %v\n", err)
 			}
 			fmt.Printf("Response is |%v|\n", respPkg)
 		} else if ingest.src != nil {
-			respSrc, err := model.IsOccurrenceSrc(context.Background(), client, ingest.src, ingest.art, ingest.occurrence)
+			respSrc, err := model.IsOccurrenceSrc(ctx, client, ingest.src, ingest.art, ingest.occurrence)
 			if err != nil {
 				logger.Errorf("Error in ingesting: %v\n", err)
 			}
@@ -217,4 +222,85 @@ func ingestOccurrence(ctx context.Context, client graphql.Client) {
 			fmt.Printf("input missing for pkg or src")
 		}
 	}
-}
\ No newline at end of file
+}
+
+// ingestAttestations pulls cosign/sigstore attestation bundles for a demo
+// artifact, verifies them through the sigstore collector, and maps each
+// verified predicate onto the matching GraphQL mutation.
+//
+// This demo config carries no FulcioRoot/RekorPublicKey: a real deployment
+// must supply both (see the verify subcommand's --fulcio-root and
+// --rekor-public-key flags), so as written VerifyAttestations will fail
+// closed on every bundle rather than ingest anything unverified.
+func ingestAttestations(ctx context.Context, client graphql.Client) {
+	logger := logging.FromContext(ctx)
+
+	config := sigstore.Config{
+		Sources:          []string{"sha256:6bbb0da1891646e58eb3e6a63af3a6fc3c8eb5a0d44824cba581d2e14a0450cf"},
+		Mode:             sigstore.AuthModeKeyless,
+		ExpectedIssuer:   "https://token.actions.githubusercontent.com",
+		ExpectedIdentity: "https://github.com/guacsec/guac/.github/workflows/release.yml@refs/heads/main",
+		RekorURL:         "https://rekor.sigstore.dev",
+	}
+
+	results, err := sigstore.VerifyAttestations(ctx, config)
+	if err != nil {
+		logger.Errorf("Error in verifying attestations: %v\n", err)
+		return
+	}
+
+	art := model.ArtifactInputSpec{Digest: "6bbb0da1891646e58eb3e6a63af3a6fc3c8eb5a0d44824cba581d2e14a0450cf", Algorithm: "sha256"}
+
+	for _, result := range results {
+		origin := fmt.Sprintf("sigstore: rekor log index %d, signer %s", result.Inclusion.LogIndex, result.SignerIdentity)
+
+		switch result.Predicate {
+		case sigstore.PredicateSLSAProvenance:
+			resp, err := model.HasSlsa(ctx, client, art, model.HasSLSAInputSpec{
+				Origin:    origin,
+				Collector: "sigstore",
+			})
+			if err != nil {
+				logger.Errorf("Error in ingesting: %v\n", err)
+			}
+			fmt.Printf("Response is |%v|\n", resp)
+		case sigstore.PredicateSBOM:
+			resp, err := model.HasSBOM(ctx, client, art, model.HasSBOMInputSpec{
+				Origin:    origin,
+				Collector: "sigstore",
+			})
+			if err != nil {
+				logger.Errorf("Error in ingesting: %v\n", err)
+			}
+			fmt.Printf("Response is |%v|\n", resp)
+		case sigstore.PredicateVEX:
+			resp, err := model.CertifyVEXStatement(ctx, client, art, model.VexStatementInputSpec{
+				Origin:    origin,
+				Collector: "sigstore",
+			})
+			if err != nil {
+				logger.Errorf("Error in ingesting: %v\n", err)
+			}
+			fmt.Printf("Response is |%v|\n", resp)
+		case sigstore.PredicateOccurrence:
+			srcTag := "v0.1.0"
+			src := &model.SourceInputSpec{
+				Type:      "git",
+				Namespace: "github",
+				Name:      "github.com/guacsec/guac",
+				Tag:       &srcTag,
+			}
+			resp, err := model.IsOccurrenceSrc(ctx, client, src, art, model.IsOccurrenceSpecInputSpec{
+				Justification: "sigstore-verified occurrence attestation",
+				Origin:        origin,
+				Collector:     "sigstore",
+			})
+			if err != nil {
+				logger.Errorf("Error in ingesting: %v\n", err)
+			}
+			fmt.Printf("Response is |%v|\n", resp)
+		default:
+			logger.Errorf("Unhandled attestation predicate for %q: %v\n", result.Subject, result.Predicate)
+		}
+	}
+}
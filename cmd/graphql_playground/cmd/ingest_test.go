@@ -0,0 +1,83 @@
+//
+// Copyright 2023 The GUAC Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"testing"
+
+	"github.com/guacsec/guac/pkg/assembler/clients/testserver"
+	"github.com/guacsec/guac/pkg/logging"
+)
+
+// TestIngestScorecardsSendsExpectedMutation drives the real ingestScorecards
+// ingestor against the in-process fake backend and checks the mutation it
+// sends, rather than only exercising the fake's dispatch table directly.
+func TestIngestScorecardsSendsExpectedMutation(t *testing.T) {
+	srv, client := testserver.New()
+	defer srv.Close()
+
+	ctx := logging.WithLogger(context.Background())
+	ingestScorecards(ctx, client)
+
+	if len(srv.Store.Mutations) != 1 {
+		t.Fatalf("expected 1 recorded mutation, got %d", len(srv.Store.Mutations))
+	}
+	if got := srv.Store.Mutations[0].Operation; got != "Scorecard" {
+		t.Errorf("expected a Scorecard mutation, got %q", got)
+	}
+}
+
+// TestIngestDependencySendsExpectedMutations drives ingestDependency, which
+// ingests more than one dependency edge, and checks both were sent as
+// IsDependency mutations.
+func TestIngestDependencySendsExpectedMutations(t *testing.T) {
+	srv, client := testserver.New()
+	defer srv.Close()
+
+	ctx := logging.WithLogger(context.Background())
+	ingestDependency(ctx, client)
+
+	if len(srv.Store.Mutations) != 2 {
+		t.Fatalf("expected 2 recorded mutations, got %d", len(srv.Store.Mutations))
+	}
+	for i, m := range srv.Store.Mutations {
+		if m.Operation != "IsDependency" {
+			t.Errorf("mutation %d: expected IsDependency, got %q", i, m.Operation)
+		}
+	}
+}
+
+// TestIngestOccurrenceSendsExpectedMutations drives ingestOccurrence, which
+// covers both subject shapes it supports across its three entries (package,
+// package, source), and checks the right operation was sent for each.
+func TestIngestOccurrenceSendsExpectedMutations(t *testing.T) {
+	srv, client := testserver.New()
+	defer srv.Close()
+
+	ctx := logging.WithLogger(context.Background())
+	ingestOccurrence(ctx, client)
+
+	want := []string{"IsOccurrencePkg", "IsOccurrencePkg", "IsOccurrenceSrc"}
+	if len(srv.Store.Mutations) != len(want) {
+		t.Fatalf("expected %d recorded mutations, got %d", len(want), len(srv.Store.Mutations))
+	}
+	for i, op := range want {
+		if got := srv.Store.Mutations[i].Operation; got != op {
+			t.Errorf("mutation %d: expected %q, got %q", i, op, got)
+		}
+	}
+}
@@ -0,0 +1,343 @@
+//
+// Copyright 2023 The GUAC Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/Khan/genqlient/graphql"
+	"github.com/guacsec/guac/pkg/handler/collector/sigstore"
+	"github.com/guacsec/guac/pkg/logging"
+	"github.com/spf13/cobra"
+)
+
+// verifyNodeIDs is filled in by the --node-id flag and resolved against the
+// backend the same way getIDfromNode resolves a node to its subject ID.
+var verifyNodeIDs []string
+
+// verifyGQLAddr is the GraphQL endpoint to resolve --node-id against,
+// matching the "http://localhost:{port}/query" convention ingestData uses.
+var verifyGQLAddr string
+
+// verifyMode, verifyRekorURL, verifyFulcioRootPath, verifyRekorPublicKeyPath,
+// verifyExpectedIssuer, verifyExpectedIdentity, and verifyPublicKeyPath hold
+// the trust material for re-verification, filled in by their matching flags
+// and threaded into a sigstore.Config per node below.
+var (
+	verifyMode               string
+	verifyRekorURL           string
+	verifyFulcioRootPath     string
+	verifyRekorPublicKeyPath string
+	verifyExpectedIssuer     string
+	verifyExpectedIdentity   string
+	verifyPublicKeyPath      string
+)
+
+var verifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "re-verify previously ingested attestations against their Rekor entries",
+	Long: `verify walks the nodes given by --node-id, resolves each one to the
+artifact it certifies, and re-checks the corresponding Rekor transparency-log
+entry on demand. Use this to confirm that an attestation ingested earlier is
+still backed by a valid, unrevoked log entry.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := logging.WithLogger(context.Background())
+
+		config, err := sigstoreConfigFromFlags()
+		if err != nil {
+			logging.FromContext(ctx).Errorf("verify failed: %v", err)
+			return
+		}
+
+		client := graphql.NewClient(verifyGQLAddr, &http.Client{})
+		if err := runVerify(ctx, client, config, verifyNodeIDs); err != nil {
+			logging.FromContext(ctx).Errorf("verify failed: %v", err)
+		}
+	},
+}
+
+func init() {
+	verifyCmd.Flags().StringSliceVar(&verifyNodeIDs, "node-id", nil, "GraphQL node IDs of the certifications to re-verify")
+	verifyCmd.Flags().StringVar(&verifyGQLAddr, "gql-addr", "http://localhost:8080/query", "address of the GraphQL endpoint to resolve node IDs against")
+	verifyCmd.Flags().StringVar(&verifyMode, "mode", "keyless", `verification mode: "keyless" (Fulcio certificate chain + OIDC identity) or "key" (detached public key)`)
+	verifyCmd.Flags().StringVar(&verifyRekorURL, "rekor-url", "https://rekor.sigstore.dev", "address of the Rekor transparency log to search and fetch entries from")
+	verifyCmd.Flags().StringVar(&verifyFulcioRootPath, "fulcio-root", "", "path to the PEM-encoded Fulcio root certificate (required for --mode=keyless)")
+	verifyCmd.Flags().StringVar(&verifyRekorPublicKeyPath, "rekor-public-key", "", "path to the PEM-encoded Rekor log public key used to authenticate inclusion proofs (required)")
+	verifyCmd.Flags().StringVar(&verifyExpectedIssuer, "expected-issuer", "", "OIDC issuer the signing certificate must have been minted for (--mode=keyless)")
+	verifyCmd.Flags().StringVar(&verifyExpectedIdentity, "expected-identity", "", "OIDC identity the signing certificate must have been minted for (--mode=keyless)")
+	verifyCmd.Flags().StringVar(&verifyPublicKeyPath, "public-key", "", "path to the PEM-encoded public key the signature was produced with (required for --mode=key)")
+	rootCmd.AddCommand(verifyCmd)
+}
+
+// sigstoreConfigFromFlags builds the trust material shared by every
+// re-verified node from the flags above, reading PEM files once up front
+// rather than per node.
+func sigstoreConfigFromFlags() (sigstore.Config, error) {
+	mode := sigstore.AuthModeKeyless
+	if verifyMode == "key" {
+		mode = sigstore.AuthModeKey
+	} else if verifyMode != "keyless" {
+		return sigstore.Config{}, fmt.Errorf("unsupported --mode %q, want \"keyless\" or \"key\"", verifyMode)
+	}
+
+	if verifyRekorPublicKeyPath == "" {
+		return sigstore.Config{}, fmt.Errorf("--rekor-public-key is required: verification cannot authenticate Rekor inclusion proofs without it")
+	}
+	rekorPublicKey, err := os.ReadFile(verifyRekorPublicKeyPath)
+	if err != nil {
+		return sigstore.Config{}, fmt.Errorf("failed to read --rekor-public-key %q: %w", verifyRekorPublicKeyPath, err)
+	}
+
+	config := sigstore.Config{
+		Mode:             mode,
+		RekorURL:         verifyRekorURL,
+		RekorPublicKey:   rekorPublicKey,
+		ExpectedIssuer:   verifyExpectedIssuer,
+		ExpectedIdentity: verifyExpectedIdentity,
+	}
+
+	if mode == sigstore.AuthModeKeyless {
+		if verifyFulcioRootPath == "" {
+			return sigstore.Config{}, fmt.Errorf("--fulcio-root is required for --mode=keyless")
+		}
+		fulcioRoot, err := os.ReadFile(verifyFulcioRootPath)
+		if err != nil {
+			return sigstore.Config{}, fmt.Errorf("failed to read --fulcio-root %q: %w", verifyFulcioRootPath, err)
+		}
+		config.FulcioRoot = fulcioRoot
+	} else {
+		if verifyPublicKeyPath == "" {
+			return sigstore.Config{}, fmt.Errorf("--public-key is required for --mode=key")
+		}
+		publicKey, err := os.ReadFile(verifyPublicKeyPath)
+		if err != nil {
+			return sigstore.Config{}, fmt.Errorf("failed to read --public-key %q: %w", verifyPublicKeyPath, err)
+		}
+		config.PublicKey = publicKey
+	}
+
+	return config, nil
+}
+
+// runVerify resolves each node ID to its subject and re-checks its Rekor
+// entry, reporting one line of structured status per node. baseConfig
+// carries the trust material (Rekor/Fulcio/key material) shared by every
+// node; only Sources varies per node.
+func runVerify(ctx context.Context, client graphql.Client, baseConfig sigstore.Config, nodeIDs []string) error {
+	logger := logging.FromContext(ctx)
+
+	if len(nodeIDs) == 0 {
+		return fmt.Errorf("no --node-id given to verify")
+	}
+
+	for _, nodeID := range nodeIDs {
+		subject, err := resolveSubjectFromNodeID(ctx, client, nodeID)
+		if err != nil {
+			logger.Errorf("failed to resolve node %q to a subject: %v", nodeID, err)
+			continue
+		}
+
+		config := baseConfig
+		config.Sources = []string{subject}
+
+		results, err := sigstore.VerifyAttestations(ctx, config)
+		if err != nil {
+			logger.Errorf("re-verification failed for node %q (subject %q): %v", nodeID, subject, err)
+			continue
+		}
+
+		if len(results) == 0 {
+			logger.Errorf("node %q subject %q: no valid attestation found on re-verification", nodeID, subject)
+			continue
+		}
+
+		for _, result := range results {
+			logger.Infof("node %q subject %q: verified, signer=%q rekor-log-index=%d", nodeID, subject, result.SignerIdentity, result.Inclusion.LogIndex)
+		}
+	}
+
+	return nil
+}
+
+// subjectFragment is the Package/Artifact/Source fragment spread wherever a
+// union subject (PackageOrArtifact, PackageOrSource) can appear: directly on
+// the queried node, or nested under a certification's "subject"/"artifact"
+// field.
+const subjectFragment = `
+    __typename
+    ... on Artifact {
+      algorithm
+      digest
+    }
+    ... on Package {
+      namespaces {
+        names {
+          name
+          versions {
+            purl
+          }
+        }
+      }
+    }
+    ... on Source {
+      namespaces {
+        names {
+          name
+          tag
+        }
+      }
+    }`
+
+// nodeQuery resolves nodeID to either an Artifact/Package/Source directly, or
+// to the subject/artifact a HasSlsa/HasSbom/CertifyVEXStatement/IsOccurrence
+// certification was recorded against - the ID shapes ingestAttestations
+// actually produces and --node-id is documented to accept.
+var nodeQuery = fmt.Sprintf(`
+query ResolveNode($id: ID!) {
+  node(id: $id) {
+    __typename
+%[1]s
+    ... on HasSlsa {
+      subject {
+%[1]s
+      }
+    }
+    ... on HasSbom {
+      subject {
+%[1]s
+      }
+    }
+    ... on CertifyVEXStatement {
+      subject {
+%[1]s
+      }
+    }
+    ... on IsOccurrence {
+      artifact {
+%[1]s
+      }
+    }
+  }
+}`, subjectFragment)
+
+// subjectNode is the shape of one resolved Package/Artifact/Source value,
+// whether it's the queried node itself or nested under a certification's
+// subject/artifact field.
+type subjectNode struct {
+	Typename   string `json:"__typename"`
+	Algorithm  string `json:"algorithm"`
+	Digest     string `json:"digest"`
+	Namespaces []struct {
+		Names []struct {
+			Name     string `json:"name"`
+			Tag      string `json:"tag"`
+			Versions []struct {
+				Purl string `json:"purl"`
+			} `json:"versions"`
+		} `json:"names"`
+	} `json:"namespaces"`
+}
+
+// nodeQueryResponse mirrors the handful of Node fields resolveSubjectFromNodeID
+// needs, following the same Package/Source namespace/name/version unwrapping
+// getIDfromNode performs on the backend, but walked forwards from an ID
+// rather than from a resolved node.
+type nodeQueryResponse struct {
+	Node struct {
+		Typename string `json:"__typename"`
+		subjectNode
+
+		// Subject is populated when Typename is HasSlsa, HasSbom, or
+		// CertifyVEXStatement, none of which carry an artifact/package/
+		// source identity directly - only a pointer to one via Subject.
+		Subject *subjectNode `json:"subject"`
+
+		// Artifact is populated when Typename is IsOccurrence, which
+		// records the artifact a package/source was observed as rather
+		// than carrying an artifact identity of its own.
+		Artifact *subjectNode `json:"artifact"`
+	} `json:"node"`
+}
+
+// resolveSubjectFromNodeID looks up the node behind nodeID through the
+// GraphQL API and returns the artifact digest, package purl, or source
+// reference it certifies, so that value can be re-submitted to Rekor as a
+// search key. nodeID may name an Artifact/Package/Source directly, or a
+// HasSlsa/HasSbom/CertifyVEXStatement/IsOccurrence certification - the IDs
+// ingestAttestations actually hands back.
+func resolveSubjectFromNodeID(ctx context.Context, client graphql.Client, nodeID string) (string, error) {
+	req := &graphql.Request{
+		OpName: "ResolveNode",
+		Query:  nodeQuery,
+		Variables: &struct {
+			ID string `json:"id"`
+		}{ID: nodeID},
+	}
+
+	var resp nodeQueryResponse
+	gqlResp := &graphql.Response{Data: &resp}
+	if err := client.MakeRequest(ctx, req, gqlResp); err != nil {
+		return "", fmt.Errorf("failed to query node %q: %w", nodeID, err)
+	}
+
+	switch resp.Node.Typename {
+	case "Artifact", "Package", "Source":
+		return subjectIdentity(nodeID, resp.Node.Typename, resp.Node.subjectNode)
+	case "HasSlsa", "HasSbom", "CertifyVEXStatement":
+		if resp.Node.Subject == nil {
+			return "", fmt.Errorf("node %q is a %s certification with no resolvable subject", nodeID, resp.Node.Typename)
+		}
+		return subjectIdentity(nodeID, resp.Node.Subject.Typename, *resp.Node.Subject)
+	case "IsOccurrence":
+		if resp.Node.Artifact == nil {
+			return "", fmt.Errorf("node %q is an IsOccurrence certification with no resolvable artifact", nodeID)
+		}
+		return subjectIdentity(nodeID, resp.Node.Artifact.Typename, *resp.Node.Artifact)
+	default:
+		return "", fmt.Errorf("node %q has unsupported type %q", nodeID, resp.Node.Typename)
+	}
+}
+
+// subjectIdentity derives the artifact digest, package purl, or source name
+// resolveSubjectFromNodeID returns from a resolved Package/Artifact/Source
+// value. typename disambiguates Package from Source, since both walk the
+// same namespaces/names shape but only Package carries a versions/purl
+// level beneath it.
+func subjectIdentity(nodeID, typename string, s subjectNode) (string, error) {
+	if s.Digest != "" {
+		return fmt.Sprintf("%s:%s", s.Algorithm, s.Digest), nil
+	}
+
+	if len(s.Namespaces) == 0 || len(s.Namespaces[0].Names) == 0 {
+		return "", fmt.Errorf("node %q has no resolvable artifact digest, package version, or source name", nodeID)
+	}
+	name := s.Namespaces[0].Names[0]
+
+	switch typename {
+	case "Package":
+		if len(name.Versions) == 0 {
+			return "", fmt.Errorf("node %q is a package with no resolvable version", nodeID)
+		}
+		return name.Versions[0].Purl, nil
+	case "Source":
+		return name.Name, nil
+	default:
+		return "", fmt.Errorf("node %q has unrecognized subject type %q", nodeID, typename)
+	}
+}
@@ -0,0 +1,306 @@
+//
+// Copyright 2023 The GUAC Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package testserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// gqlRequest is the body genqlient sends for every operation: a query
+// document, its variables, and the operation name used to pick a handler
+// below.
+type gqlRequest struct {
+	Query         string          `json:"query"`
+	Variables     json.RawMessage `json:"variables"`
+	OperationName string          `json:"operationName"`
+}
+
+type gqlError struct {
+	Message string `json:"message"`
+}
+
+type gqlResponse struct {
+	Data   any        `json:"data,omitempty"`
+	Errors []gqlError `json:"errors,omitempty"`
+}
+
+// handleQuery implements just enough of the assembler's GraphQL schema to
+// satisfy ingestScorecards/ingestDependency/ingestOccurrence: it dispatches
+// on OperationName the same way the generated resolvers dispatch on field
+// name, applies the mutation to s.Store, and records it.
+func (s *Server) handleQuery(w http.ResponseWriter, r *http.Request) {
+	var req gqlRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, fmt.Errorf("failed to decode request: %w", err))
+		return
+	}
+
+	s.Store.record(req.OperationName, req.Variables)
+
+	data, err := s.dispatch(req.OperationName, req.Variables)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, gqlResponse{Data: data})
+}
+
+func (s *Server) dispatch(operation string, variables json.RawMessage) (any, error) {
+	switch operation {
+	case "Scorecard":
+		return s.resolveScorecard(variables)
+	case "IsDependency":
+		return s.resolveIsDependency(variables)
+	case "IsOccurrencePkg":
+		return s.resolveIsOccurrencePkg(variables)
+	case "IsOccurrenceSrc":
+		return s.resolveIsOccurrenceSrc(variables)
+	case "ResolveNode":
+		return s.resolveNode(variables)
+	default:
+		return nil, fmt.Errorf("testserver: unhandled operation %q", operation)
+	}
+}
+
+func (s *Server) resolveScorecard(variables json.RawMessage) (any, error) {
+	var in struct {
+		Source struct {
+			Type      string  `json:"type"`
+			Namespace string  `json:"namespace"`
+			Name      string  `json:"name"`
+			Tag       *string `json:"tag"`
+		} `json:"source"`
+		Scorecard struct {
+			AggregateScore float64 `json:"aggregateScore"`
+			Origin         string  `json:"origin"`
+			Collector      string  `json:"collector"`
+		} `json:"scorecard"`
+	}
+	if err := json.Unmarshal(variables, &in); err != nil {
+		return nil, fmt.Errorf("failed to decode Scorecard variables: %w", err)
+	}
+
+	sourceKey := fmt.Sprintf("%s/%s/%s", in.Source.Type, in.Source.Namespace, in.Source.Name)
+	id := s.Store.ingestScorecard(sourceKey, scorecardRecord{
+		SourceKey: sourceKey,
+		AggScore:  in.Scorecard.AggregateScore,
+		Origin:    in.Scorecard.Origin,
+		Collector: in.Scorecard.Collector,
+	})
+
+	return map[string]string{"ingestScorecard": id}, nil
+}
+
+func (s *Server) resolveIsDependency(variables json.RawMessage) (any, error) {
+	var in struct {
+		Pkg        pkgInputSpec `json:"pkg"`
+		DepPkg     pkgInputSpec `json:"depPkg"`
+		Dependency struct {
+			VersionRange string `json:"versionRange"`
+			Origin       string `json:"origin"`
+			Collector    string `json:"collector"`
+		} `json:"dependency"`
+	}
+	if err := json.Unmarshal(variables, &in); err != nil {
+		return nil, fmt.Errorf("failed to decode IsDependency variables: %w", err)
+	}
+
+	id := s.Store.ingestDependency(in.Pkg.key(), in.DepPkg.key(), dependencyRecord{
+		PkgKey:    in.Pkg.key(),
+		DepPkgKey: in.DepPkg.key(),
+		Version:   in.Dependency.VersionRange,
+		Origin:    in.Dependency.Origin,
+		Collector: in.Dependency.Collector,
+	})
+
+	return map[string]string{"ingestDependency": id}, nil
+}
+
+func (s *Server) resolveIsOccurrencePkg(variables json.RawMessage) (any, error) {
+	var in struct {
+		Pkg      pkgInputSpec `json:"pkg"`
+		Artifact struct {
+			Digest    string `json:"digest"`
+			Algorithm string `json:"algorithm"`
+		} `json:"artifact"`
+		Occurrence struct {
+			Origin    string `json:"origin"`
+			Collector string `json:"collector"`
+		} `json:"occurrence"`
+	}
+	if err := json.Unmarshal(variables, &in); err != nil {
+		return nil, fmt.Errorf("failed to decode IsOccurrencePkg variables: %w", err)
+	}
+
+	id := s.Store.ingestOccurrence(in.Pkg.key(), in.Artifact.Digest, occurrenceRecord{
+		SubjectKey: in.Pkg.key(),
+		ArtDigest:  in.Artifact.Digest,
+		Origin:     in.Occurrence.Origin,
+		Collector:  in.Occurrence.Collector,
+	})
+
+	s.registerPackageNode(in.Pkg)
+	s.registerArtifactNode(in.Artifact.Algorithm, in.Artifact.Digest)
+
+	return map[string]string{"ingestOccurrence": id}, nil
+}
+
+func (s *Server) resolveIsOccurrenceSrc(variables json.RawMessage) (any, error) {
+	var in struct {
+		Source struct {
+			Type      string  `json:"type"`
+			Namespace string  `json:"namespace"`
+			Name      string  `json:"name"`
+			Tag       *string `json:"tag"`
+		} `json:"source"`
+		Artifact struct {
+			Digest    string `json:"digest"`
+			Algorithm string `json:"algorithm"`
+		} `json:"artifact"`
+		Occurrence struct {
+			Origin    string `json:"origin"`
+			Collector string `json:"collector"`
+		} `json:"occurrence"`
+	}
+	if err := json.Unmarshal(variables, &in); err != nil {
+		return nil, fmt.Errorf("failed to decode IsOccurrenceSrc variables: %w", err)
+	}
+
+	sourceKey := fmt.Sprintf("%s/%s/%s", in.Source.Type, in.Source.Namespace, in.Source.Name)
+	id := s.Store.ingestOccurrence(sourceKey, in.Artifact.Digest, occurrenceRecord{
+		SubjectKey: sourceKey,
+		ArtDigest:  in.Artifact.Digest,
+		Origin:     in.Occurrence.Origin,
+		Collector:  in.Occurrence.Collector,
+	})
+
+	s.registerSourceNode(in.Source.Type, in.Source.Namespace, in.Source.Name, in.Source.Tag)
+	s.registerArtifactNode(in.Artifact.Algorithm, in.Artifact.Digest)
+
+	return map[string]string{"ingestOccurrence": id}, nil
+}
+
+// registerPackageNode records pkg under Store's Package node registry,
+// shaped to match the namespaces->names->versions->purl fragment a Node
+// query asks for.
+func (s *Server) registerPackageNode(pkg pkgInputSpec) {
+	version := ""
+	if pkg.Version != nil {
+		version = *pkg.Version
+	}
+	purl := fmt.Sprintf("pkg:%s/%s@%s", pkg.Type, pkg.Name, version)
+	if pkg.Namespace != nil && *pkg.Namespace != "" {
+		purl = fmt.Sprintf("pkg:%s/%s/%s@%s", pkg.Type, *pkg.Namespace, pkg.Name, version)
+	}
+
+	id := s.Store.NodeID("Package", pkg.key())
+	s.Store.RegisterNode(id, "Package", map[string]any{
+		"namespaces": []map[string]any{{
+			"names": []map[string]any{{
+				"name": pkg.Name,
+				"versions": []map[string]any{{
+					"purl": purl,
+				}},
+			}},
+		}},
+	})
+}
+
+// registerSourceNode records a Source node shaped to match the
+// namespaces->names->{name,tag} fragment a Node query asks for.
+func (s *Server) registerSourceNode(srcType, namespace, name string, tag *string) {
+	tagValue := ""
+	if tag != nil {
+		tagValue = *tag
+	}
+
+	key := fmt.Sprintf("%s/%s/%s", srcType, namespace, name)
+	id := s.Store.NodeID("Source", key)
+	s.Store.RegisterNode(id, "Source", map[string]any{
+		"namespaces": []map[string]any{{
+			"names": []map[string]any{{
+				"name": name,
+				"tag":  tagValue,
+			}},
+		}},
+	})
+}
+
+// registerArtifactNode records an Artifact node shaped to match the
+// algorithm/digest fragment a Node query asks for.
+func (s *Server) registerArtifactNode(algorithm, digest string) {
+	id := s.Store.NodeID("Artifact", algorithm+":"+digest)
+	s.Store.RegisterNode(id, "Artifact", map[string]any{
+		"algorithm": algorithm,
+		"digest":    digest,
+	})
+}
+
+// resolveNode answers a Node(id) query against the fake's node registry,
+// the same entry point getIDfromNode resolves against on the real backend.
+func (s *Server) resolveNode(variables json.RawMessage) (any, error) {
+	var in struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(variables, &in); err != nil {
+		return nil, fmt.Errorf("failed to decode ResolveNode variables: %w", err)
+	}
+
+	typename, fields, ok := s.Store.LookupNode(in.ID)
+	if !ok {
+		return nil, fmt.Errorf("testserver: no node registered for id %q", in.ID)
+	}
+
+	node := map[string]any{"__typename": typename}
+	for k, v := range fields {
+		node[k] = v
+	}
+
+	return map[string]any{"node": node}, nil
+}
+
+// pkgInputSpec decodes just the fields of model.PkgInputSpec this fake needs
+// to key a package by.
+type pkgInputSpec struct {
+	Type      string  `json:"type"`
+	Namespace *string `json:"namespace"`
+	Name      string  `json:"name"`
+	Version   *string `json:"version"`
+}
+
+func (p pkgInputSpec) key() string {
+	ns := ""
+	if p.Namespace != nil {
+		ns = *p.Namespace
+	}
+	version := ""
+	if p.Version != nil {
+		version = *p.Version
+	}
+	return fmt.Sprintf("%s/%s/%s@%s", p.Type, ns, p.Name, version)
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	writeJSON(w, gqlResponse{Errors: []gqlError{{Message: err.Error()}}})
+}
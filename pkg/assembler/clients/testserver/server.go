@@ -0,0 +1,57 @@
+//
+// Copyright 2023 The GUAC Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package testserver
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/Khan/genqlient/graphql"
+)
+
+// Server is an in-process fake of the assembler's GraphQL endpoint, backed
+// by Store. It is ready to serve the moment New returns: there is no
+// arbitrary startup sleep to race against, unlike pointing a client at a
+// real server that may still be binding its listener.
+type Server struct {
+	httpServer *httptest.Server
+	Store      *Store
+}
+
+// New starts a Server and returns it along with a graphql.Client already
+// pointed at it. Call Close when done.
+func New() (*Server, graphql.Client) {
+	store := NewStore()
+	s := &Server{Store: store}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/query", s.handleQuery)
+	s.httpServer = httptest.NewServer(mux)
+
+	client := graphql.NewClient(s.httpServer.URL+"/query", s.httpServer.Client())
+	return s, client
+}
+
+// Close shuts down the underlying httptest.Server.
+func (s *Server) Close() {
+	s.httpServer.Close()
+}
+
+// URL returns the base URL of the fake server, e.g. for tests that want to
+// build their own client.
+func (s *Server) URL() string {
+	return s.httpServer.URL
+}
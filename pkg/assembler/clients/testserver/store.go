@@ -0,0 +1,212 @@
+//
+// Copyright 2023 The GUAC Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package testserver provides an in-process, schema-faithful fake of the
+// assembler's GraphQL backend for unit-testing ingestors. It mirrors the
+// pattern used by the x/crypto ACME test server: an httptest.Server backed
+// by an in-memory store, with no network dependency or arbitrary sleeps.
+package testserver
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// toGlobalID and generateUUIDKey mirror the helpers of the same name in
+// pkg/assembler/backends/ent/backend, which is where real node IDs are
+// minted. Keeping the same scheme here means fixtures built against this
+// fake exercise the same ID shapes ingestors see against the real backend.
+func toGlobalID(nodeType, id string) string {
+	return strings.Join([]string{nodeType, id}, ":")
+}
+
+func generateUUIDKey(data []byte) uuid.UUID {
+	return uuid.NewHash(sha256.New(), uuid.NameSpaceDNS, data, 5)
+}
+
+// scorecardRecord, dependencyRecord, and occurrenceRecord capture just
+// enough of each mutation's inputs to answer lookups and assertions; this
+// fake isn't a full graph, only enough of one to drive ingestor tests.
+type scorecardRecord struct {
+	SourceKey string
+	AggScore  float64
+	Collector string
+	Origin    string
+}
+
+type dependencyRecord struct {
+	PkgKey    string
+	DepPkgKey string
+	Version   string
+	Collector string
+	Origin    string
+}
+
+type occurrenceRecord struct {
+	SubjectKey string
+	ArtDigest  string
+	Collector  string
+	Origin     string
+}
+
+// nodeRecord is a minimal GraphQL Node the fake can resolve by ID: just
+// enough of Package/Source/Artifact to answer the field selections a Node
+// query asks for, mirroring what getIDfromNode resolves on the real
+// backend.
+type nodeRecord struct {
+	Typename string
+	Fields   map[string]any
+}
+
+// Store is the in-memory backing for the fake GraphQL server. All mutation
+// handlers append to (or look up against) it, and Mutations records every
+// operation the server receives in order (ResolveNode queries and unhandled
+// operations included) so tests can assert on exactly what was sent.
+type Store struct {
+	mu sync.Mutex
+
+	scorecards   map[string]scorecardRecord
+	dependencies map[string]dependencyRecord
+	occurrences  map[string]occurrenceRecord
+	nodes        map[string]nodeRecord
+
+	Mutations []RecordedMutation
+}
+
+// RecordedMutation is one operation the fake server received, kept verbatim
+// for assertions (e.g. "was IsDependency called exactly twice with this
+// version range").
+type RecordedMutation struct {
+	Operation string
+	Variables json.RawMessage
+}
+
+// NewStore returns an empty Store.
+func NewStore() *Store {
+	return &Store{
+		scorecards:   map[string]scorecardRecord{},
+		dependencies: map[string]dependencyRecord{},
+		occurrences:  map[string]occurrenceRecord{},
+		nodes:        map[string]nodeRecord{},
+	}
+}
+
+// record appends op to s.Mutations under the store's lock.
+func (s *Store) record(op string, variables json.RawMessage) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Mutations = append(s.Mutations, RecordedMutation{Operation: op, Variables: variables})
+}
+
+func (s *Store) ingestScorecard(sourceKey string, rec scorecardRecord) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := generateUUIDKey([]byte(sourceKey)).String()
+	s.scorecards[id] = rec
+	return toGlobalID("CertifyScorecard", id)
+}
+
+func (s *Store) ingestDependency(pkgKey, depPkgKey string, rec dependencyRecord) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := generateUUIDKey([]byte(pkgKey + "|" + depPkgKey)).String()
+	s.dependencies[id] = rec
+	return toGlobalID("IsDependency", id)
+}
+
+func (s *Store) ingestOccurrence(subjectKey, artDigest string, rec occurrenceRecord) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := generateUUIDKey([]byte(subjectKey + "|" + artDigest)).String()
+	s.occurrences[id] = rec
+	return toGlobalID("IsOccurrence", id)
+}
+
+// NodeID mints the stable node ID a subject key resolves to, using the same
+// generateUUIDKey scheme as the edge IDs above so a Package/Source/Artifact
+// subject gets one consistent ID across every mutation that touches it.
+func (s *Store) NodeID(typename, key string) string {
+	return toGlobalID(typename, generateUUIDKey([]byte(typename+"|"+key)).String())
+}
+
+// RegisterNode records a Package/Source/Artifact node under id so a later
+// Node query can resolve it, mirroring how the ent backend indexes every
+// subject it stores under its minted node ID.
+func (s *Store) RegisterNode(id, typename string, fields map[string]any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nodes[id] = nodeRecord{Typename: typename, Fields: fields}
+}
+
+// LookupNode returns the typename and fields registered under id, if any.
+func (s *Store) LookupNode(id string) (typename string, fields map[string]any, ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rec, ok := s.nodes[id]
+	return rec.Typename, rec.Fields, ok
+}
+
+// Snapshot is a point-in-time copy of the store's state, for table-driven
+// tests that want to share a baseline fixture across subtests and reset
+// between them.
+type Snapshot struct {
+	scorecards   map[string]scorecardRecord
+	dependencies map[string]dependencyRecord
+	occurrences  map[string]occurrenceRecord
+	nodes        map[string]nodeRecord
+	mutationLen  int
+}
+
+// Snapshot captures the current store state.
+func (s *Store) Snapshot() Snapshot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return Snapshot{
+		scorecards:   cloneMap(s.scorecards),
+		dependencies: cloneMap(s.dependencies),
+		occurrences:  cloneMap(s.occurrences),
+		nodes:        cloneMap(s.nodes),
+		mutationLen:  len(s.Mutations),
+	}
+}
+
+// Restore resets the store to a previously captured Snapshot, truncating
+// Mutations back to the length it had at snapshot time.
+func (s *Store) Restore(snap Snapshot) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.scorecards = cloneMap(snap.scorecards)
+	s.dependencies = cloneMap(snap.dependencies)
+	s.occurrences = cloneMap(snap.occurrences)
+	s.nodes = cloneMap(snap.nodes)
+	s.Mutations = s.Mutations[:snap.mutationLen]
+}
+
+func cloneMap[K comparable, V any](m map[K]V) map[K]V {
+	out := make(map[K]V, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+	return out
+}
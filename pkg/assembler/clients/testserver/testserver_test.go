@@ -0,0 +1,189 @@
+//
+// Copyright 2023 The GUAC Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package testserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+// post sends a minimal GraphQL request body to the fake: dispatch only
+// looks at operationName, so the query text itself doesn't need to be a
+// real document.
+func post(t *testing.T, url, operationName string, variables any) map[string]any {
+	t.Helper()
+
+	varBytes, err := json.Marshal(variables)
+	if err != nil {
+		t.Fatalf("failed to marshal variables: %v", err)
+	}
+
+	body, err := json.Marshal(gqlRequest{
+		Query:         "query { __typename }",
+		OperationName: operationName,
+		Variables:     varBytes,
+	})
+	if err != nil {
+		t.Fatalf("failed to marshal request: %v", err)
+	}
+
+	resp, err := http.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var out gqlResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(out.Errors) > 0 {
+		t.Fatalf("server returned errors: %v", out.Errors)
+	}
+
+	data, ok := out.Data.(map[string]any)
+	if !ok {
+		t.Fatalf("expected object data, got %T", out.Data)
+	}
+	return data
+}
+
+func TestMutationsRecordedInOrder(t *testing.T) {
+	srv, _ := New()
+	defer srv.Close()
+
+	post(t, srv.URL()+"/query", "IsOccurrenceSrc", map[string]any{
+		"source": map[string]any{
+			"type":      "git",
+			"namespace": "github",
+			"name":      "github.com/guacsec/guac",
+			"tag":       "v0.1.0",
+		},
+		"artifact": map[string]any{
+			"digest":    "abc123",
+			"algorithm": "sha256",
+		},
+		"occurrence": map[string]any{
+			"origin":    "test",
+			"collector": "test",
+		},
+	})
+
+	post(t, srv.URL()+"/query", "Scorecard", map[string]any{
+		"source": map[string]any{
+			"type":      "git",
+			"namespace": "github",
+			"name":      "github.com/guacsec/guac",
+		},
+		"scorecard": map[string]any{
+			"aggregateScore": 7.5,
+			"origin":         "test",
+			"collector":      "test",
+		},
+	})
+
+	if len(srv.Store.Mutations) != 2 {
+		t.Fatalf("expected 2 recorded mutations, got %d", len(srv.Store.Mutations))
+	}
+	if srv.Store.Mutations[0].Operation != "IsOccurrenceSrc" {
+		t.Errorf("expected first mutation to be IsOccurrenceSrc, got %q", srv.Store.Mutations[0].Operation)
+	}
+	if srv.Store.Mutations[1].Operation != "Scorecard" {
+		t.Errorf("expected second mutation to be Scorecard, got %q", srv.Store.Mutations[1].Operation)
+	}
+}
+
+func TestSnapshotRestore(t *testing.T) {
+	srv, _ := New()
+	defer srv.Close()
+
+	post(t, srv.URL()+"/query", "Scorecard", map[string]any{
+		"source": map[string]any{
+			"type":      "git",
+			"namespace": "github",
+			"name":      "github.com/guacsec/guac",
+		},
+		"scorecard": map[string]any{
+			"aggregateScore": 5.0,
+			"origin":         "test",
+			"collector":      "test",
+		},
+	})
+
+	snap := srv.Store.Snapshot()
+
+	post(t, srv.URL()+"/query", "Scorecard", map[string]any{
+		"source": map[string]any{
+			"type":      "git",
+			"namespace": "github",
+			"name":      "github.com/guacsec/another",
+		},
+		"scorecard": map[string]any{
+			"aggregateScore": 9.0,
+			"origin":         "test",
+			"collector":      "test",
+		},
+	})
+
+	if len(srv.Store.Mutations) != 2 {
+		t.Fatalf("expected 2 mutations before restore, got %d", len(srv.Store.Mutations))
+	}
+
+	srv.Store.Restore(snap)
+
+	if len(srv.Store.Mutations) != 1 {
+		t.Fatalf("expected 1 mutation after restore, got %d", len(srv.Store.Mutations))
+	}
+}
+
+func TestResolveNode(t *testing.T) {
+	srv, _ := New()
+	defer srv.Close()
+
+	tag := "v0.1.0"
+	post(t, srv.URL()+"/query", "IsOccurrenceSrc", map[string]any{
+		"source": map[string]any{
+			"type":      "git",
+			"namespace": "github",
+			"name":      "github.com/guacsec/guac",
+			"tag":       tag,
+		},
+		"artifact": map[string]any{
+			"digest":    "abc123",
+			"algorithm": "sha256",
+		},
+		"occurrence": map[string]any{
+			"origin":    "test",
+			"collector": "test",
+		},
+	})
+
+	sourceKey := fmt.Sprintf("%s/%s/%s", "git", "github", "github.com/guacsec/guac")
+	nodeID := srv.Store.NodeID("Source", sourceKey)
+
+	data := post(t, srv.URL()+"/query", "ResolveNode", map[string]any{"id": nodeID})
+
+	node, ok := data["node"].(map[string]any)
+	if !ok {
+		t.Fatalf("expected node object, got %T", data["node"])
+	}
+	if node["__typename"] != "Source" {
+		t.Errorf("expected __typename Source, got %v", node["__typename"])
+	}
+}
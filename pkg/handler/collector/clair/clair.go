@@ -0,0 +1,196 @@
+//
+// Copyright 2023 The GUAC Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package clair enriches packages already in the graph with vulnerability
+// findings from a Clair v4 indexer/matcher, translating its reports into
+// Vulnerability, CertifyVuln, and VulnerabilityMetadata mutations.
+package clair
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/guacsec/guac/pkg/logging"
+)
+
+const (
+	// defaultBatchSize mirrors the batch size the ent backend uses for its
+	// own chunked queries; Clair's indexer handles manifests one at a time
+	// but we still walk the package list in batches to bound memory and
+	// give progress checkpoints on large graphs.
+	defaultBatchSize = 100
+)
+
+// Config points the enrichment subsystem at a running Clair deployment.
+type Config struct {
+	IndexerURL string
+	MatcherURL string
+
+	// BatchSize controls how many packages are walked per chunk. Defaults
+	// to defaultBatchSize when zero.
+	BatchSize int
+
+	// UpdaterChannels restricts which Clair updater channels' findings are
+	// accepted, e.g. "ubuntu", "alpine", "rhel", "debian", "pyup". A nil
+	// slice accepts findings from any channel.
+	UpdaterChannels []string
+
+	// PollInterval is how often index_report status is polled while waiting
+	// for it to reach IndexFinished.
+	PollInterval time.Duration
+	// MaxIndexPolls bounds how many times index_report status is polled
+	// before indexAndMatch gives up waiting for IndexFinished. This is
+	// independent of MaxRetries: indexing a large manifest can legitimately
+	// take far longer than a single Clair API call should ever be retried.
+	MaxIndexPolls int
+	// MaxRetries bounds the retry/backoff applied to Clair API calls.
+	MaxRetries int
+}
+
+// PackageNode is the subset of a graph Package node the enrichment pipeline
+// needs: enough to build a purl and to tie a CertifyVuln finding back to the
+// original node once the scan completes.
+type PackageNode struct {
+	ID   string
+	Purl string
+}
+
+// VulnFinding is one vulnerability Clair reported against a package,
+// translated into the shape the graph's Vulnerability/CertifyVuln/
+// VulnerabilityMetadata mutations expect.
+type VulnFinding struct {
+	Package PackageNode
+
+	VulnerabilityID string // normalized CVE/GHSA id
+	Severity        string
+	CVSSScore       float64
+	FixedInVersion  string
+
+	TimeScanned time.Time
+	Origin      string
+
+	// CertifyVulnID is set by the caller once this finding has been
+	// ingested as a CertifyVuln edge, so a later SweepResolved call can
+	// report which edge to mark resolved.
+	CertifyVulnID string
+}
+
+// EnrichPackages walks packages in batches, indexes and matches each one
+// against the configured Clair deployment, and returns every vulnerability
+// finding across the whole set. A package that fails to index or match is
+// logged and skipped rather than aborting the run.
+func EnrichPackages(ctx context.Context, config Config, packages []PackageNode) ([]VulnFinding, error) {
+	logger := logging.FromContext(ctx)
+
+	if config.BatchSize <= 0 {
+		config.BatchSize = defaultBatchSize
+	}
+
+	client := newClairClient(config)
+
+	var findings []VulnFinding
+	for _, batch := range chunk(packages, config.BatchSize) {
+		for _, pkg := range batch {
+			manifest, err := manifestFromPurl(pkg.Purl)
+			if err != nil {
+				logger.Errorf("failed to build clair manifest for %q: %v", pkg.Purl, err)
+				continue
+			}
+
+			report, err := client.indexAndMatch(ctx, manifest)
+			if err != nil {
+				logger.Errorf("failed to scan %q with clair: %v", pkg.Purl, err)
+				continue
+			}
+
+			for _, vuln := range report.Vulnerabilities {
+				if !channelAllowed(config.UpdaterChannels, vuln.Updater) {
+					continue
+				}
+
+				severity := vuln.NormalizedSeverity
+				if severity == "" {
+					severity = vuln.Severity
+				}
+
+				findings = append(findings, VulnFinding{
+					Package:         pkg,
+					VulnerabilityID: normalizeVulnID(vuln.ID),
+					Severity:        severity,
+					CVSSScore:       vuln.CVSSScore,
+					FixedInVersion:  vuln.FixedInVersion,
+					TimeScanned:     report.ScannedAt,
+					Origin:          "clair",
+				})
+			}
+		}
+	}
+
+	return findings, nil
+}
+
+// channelAllowed reports whether channel is accepted by the configured
+// filter. A nil/empty filter accepts every channel.
+func channelAllowed(allowed []string, channel string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+	for _, a := range allowed {
+		if a == channel {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeVulnID canonicalizes a Clair vulnerability identifier down to its
+// bare CVE/GHSA id. Clair updaters report IDs prefixed with their own
+// namespace (e.g. "debian:CVE-2021-12345", "alpine:CVE-2021-12345"), and some
+// emit the bare id in lowercase; stripping the namespace prefix and
+// uppercasing makes findings from different updaters for the same CVE key
+// identically instead of producing duplicate Vulnerability nodes.
+func normalizeVulnID(id string) string {
+	id = strings.TrimSpace(id)
+	if idx := strings.LastIndex(id, ":"); idx >= 0 {
+		id = id[idx+1:]
+	}
+	return strings.ToUpper(id)
+}
+
+// chunk splits collection into slices of at most size elements each,
+// mirroring the batching helper the ent backend uses for its own chunked
+// queries.
+func chunk[T any](collection []T, size int) [][]T {
+	if size <= 0 {
+		panic("size must be greater than 0")
+	}
+
+	chunksNum := len(collection) / size
+	if len(collection)%size != 0 {
+		chunksNum++
+	}
+
+	result := make([][]T, 0, chunksNum)
+	for i := 0; i < chunksNum; i++ {
+		last := (i + 1) * size
+		if last > len(collection) {
+			last = len(collection)
+		}
+		result = append(result, collection[i*size:last])
+	}
+
+	return result
+}
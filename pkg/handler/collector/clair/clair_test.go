@@ -0,0 +1,73 @@
+//
+// Copyright 2023 The GUAC Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clair
+
+import "testing"
+
+func TestNormalizeVulnID(t *testing.T) {
+	tests := []struct {
+		name string
+		id   string
+		want string
+	}{
+		{name: "bare CVE", id: "CVE-2021-12345", want: "CVE-2021-12345"},
+		{name: "debian-prefixed", id: "debian:CVE-2021-12345", want: "CVE-2021-12345"},
+		{name: "alpine-prefixed lowercase", id: "alpine:cve-2021-12345", want: "CVE-2021-12345"},
+		{name: "GHSA id", id: "ghsa:GHSA-xxxx-yyyy-zzzz", want: "GHSA-XXXX-YYYY-ZZZZ"},
+		{name: "padded", id: "  CVE-2021-12345  ", want: "CVE-2021-12345"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := normalizeVulnID(tt.id); got != tt.want {
+				t.Errorf("normalizeVulnID(%q) = %q, want %q", tt.id, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChannelAllowed(t *testing.T) {
+	if !channelAllowed(nil, "ubuntu") {
+		t.Error("channelAllowed(nil, ...) = false, want true for an empty filter")
+	}
+
+	allowed := []string{"ubuntu", "debian"}
+	if !channelAllowed(allowed, "debian") {
+		t.Error("channelAllowed() = false, want true for a listed channel")
+	}
+	if channelAllowed(allowed, "alpine") {
+		t.Error("channelAllowed() = true, want false for an unlisted channel")
+	}
+}
+
+func TestChunk(t *testing.T) {
+	got := chunk([]int{1, 2, 3, 4, 5}, 2)
+	want := [][]int{{1, 2}, {3, 4}, {5}}
+
+	if len(got) != len(want) {
+		t.Fatalf("chunk() = %d chunks, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if len(got[i]) != len(want[i]) {
+			t.Fatalf("chunk()[%d] = %v, want %v", i, got[i], want[i])
+		}
+		for j := range want[i] {
+			if got[i][j] != want[i][j] {
+				t.Errorf("chunk()[%d][%d] = %d, want %d", i, j, got[i][j], want[i][j])
+			}
+		}
+	}
+}
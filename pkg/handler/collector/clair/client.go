@@ -0,0 +1,266 @@
+//
+// Copyright 2023 The GUAC Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clair
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const (
+	indexReportPath      = "/indexer/api/v1/index_report"
+	vulnerabilityRptPath = "/matcher/api/v1/vulnerability_report/%s"
+
+	indexStateFinished = "IndexFinished"
+	indexStateError    = "IndexError"
+
+	defaultPollInterval  = 2 * time.Second
+	defaultMaxRetries    = 5
+	defaultMaxIndexPolls = 150
+)
+
+// clairVuln is one entry from a Clair vulnerability_report response, keyed
+// by vulnerability ID in the report's "vulnerabilities" map. Field names
+// follow quay/claircore's VulnerabilityReport JSON encoding.
+type clairVuln struct {
+	ID                 string  `json:"id"`
+	Name               string  `json:"name"`
+	Severity           string  `json:"severity"`
+	NormalizedSeverity string  `json:"normalized_severity"`
+	CVSSScore          float64 `json:"cvss_score"`
+	FixedInVersion     string  `json:"fixed_in_version"`
+	Updater            string  `json:"updater"`
+}
+
+// clairReport is the matcher's vulnerability_report response, reduced to
+// what EnrichPackages needs. "vulnerabilities" is a map keyed by vuln ID,
+// not a list, in the real API.
+type clairReport struct {
+	ManifestHash    string               `json:"manifest_hash"`
+	Vulnerabilities map[string]clairVuln `json:"vulnerabilities"`
+
+	// ScannedAt is not part of the Clair response body (the report carries
+	// no scan timestamp of its own); it is filled in from the HTTP
+	// response's Date header in fetchVulnerabilityReport so it still
+	// reflects real response data rather than time.Now() called at an
+	// arbitrary point after the fact.
+	ScannedAt time.Time `json:"-"`
+}
+
+// clairClient drives the indexer/matcher request/poll/fetch sequence for
+// one manifest at a time.
+type clairClient struct {
+	httpClient    *http.Client
+	indexerURL    string
+	matcherURL    string
+	pollInterval  time.Duration
+	maxRetries    int
+	maxIndexPolls int
+}
+
+func newClairClient(config Config) *clairClient {
+	poll := config.PollInterval
+	if poll <= 0 {
+		poll = defaultPollInterval
+	}
+	retries := config.MaxRetries
+	if retries <= 0 {
+		retries = defaultMaxRetries
+	}
+	indexPolls := config.MaxIndexPolls
+	if indexPolls <= 0 {
+		indexPolls = defaultMaxIndexPolls
+	}
+
+	return &clairClient{
+		httpClient:    &http.Client{},
+		indexerURL:    config.IndexerURL,
+		matcherURL:    config.MatcherURL,
+		pollInterval:  poll,
+		maxRetries:    retries,
+		maxIndexPolls: indexPolls,
+	}
+}
+
+// indexAndMatch posts m to the indexer, polls until it reaches
+// IndexFinished, and then fetches the resulting vulnerability report from
+// the matcher.
+func (c *clairClient) indexAndMatch(ctx context.Context, m *manifest) (*clairReport, error) {
+	if err := c.withRetry(ctx, func() error {
+		return c.submitIndexReport(ctx, m)
+	}); err != nil {
+		return nil, fmt.Errorf("failed to submit index report for %q: %w", m.Hash, err)
+	}
+
+	if err := c.pollUntilIndexed(ctx, m.Hash); err != nil {
+		return nil, fmt.Errorf("failed waiting for index report %q: %w", m.Hash, err)
+	}
+
+	var report *clairReport
+	if err := c.withRetry(ctx, func() error {
+		r, err := c.fetchVulnerabilityReport(ctx, m.Hash)
+		if err != nil {
+			return err
+		}
+		report = r
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("failed to fetch vulnerability report for %q: %w", m.Hash, err)
+	}
+
+	return report, nil
+}
+
+// submitIndexReport POSTs m to the indexer's index_report endpoint.
+func (c *clairClient) submitIndexReport(ctx context.Context, m *manifest) error {
+	body, err := json.Marshal(m)
+	if err != nil {
+		return fmt.Errorf("failed to marshal manifest: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.indexerURL+indexReportPath, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to build index_report request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("index_report request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("index_report returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// pollUntilIndexed polls the indexer until manifestHash reaches
+// IndexFinished, the indexer reports a terminal IndexError, c.maxIndexPolls
+// polls have elapsed with no terminal state, or the context is cancelled.
+// This is bounded independently of c.maxRetries: indexing a large manifest
+// can legitimately take far longer than a single Clair API call should ever
+// be retried.
+func (c *clairClient) pollUntilIndexed(ctx context.Context, manifestHash string) error {
+	ticker := time.NewTicker(c.pollInterval)
+	defer ticker.Stop()
+
+	for attempt := 0; attempt < c.maxIndexPolls; attempt++ {
+		state, err := c.indexState(ctx, manifestHash)
+		if err != nil {
+			return err
+		}
+		switch state {
+		case indexStateFinished:
+			return nil
+		case indexStateError:
+			return fmt.Errorf("indexer reported %s for manifest %q", indexStateError, manifestHash)
+		}
+
+		if attempt == c.maxIndexPolls-1 {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+
+	return fmt.Errorf("manifest %q did not reach %s after %d polls", manifestHash, indexStateFinished, c.maxIndexPolls)
+}
+
+// indexState fetches the current indexing state for manifestHash.
+func (c *clairClient) indexState(ctx context.Context, manifestHash string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s%s/%s", c.indexerURL, indexReportPath, manifestHash), nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to build index_report status request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("index_report status request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		State string `json:"state"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("failed to decode index_report status: %w", err)
+	}
+
+	return body.State, nil
+}
+
+// fetchVulnerabilityReport GETs the matcher's report for manifestHash.
+func (c *clairClient) fetchVulnerabilityReport(ctx context.Context, manifestHash string) (*clairReport, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.matcherURL+fmt.Sprintf(vulnerabilityRptPath, manifestHash), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build vulnerability_report request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("vulnerability_report request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("vulnerability_report returned status %d", resp.StatusCode)
+	}
+
+	var report clairReport
+	if err := json.NewDecoder(resp.Body).Decode(&report); err != nil {
+		return nil, fmt.Errorf("failed to decode vulnerability_report: %w", err)
+	}
+
+	if scannedAt, err := http.ParseTime(resp.Header.Get("Date")); err == nil {
+		report.ScannedAt = scannedAt
+	} else {
+		return nil, fmt.Errorf("vulnerability_report response for %q carried no usable Date header: %w", manifestHash, err)
+	}
+
+	return &report, nil
+}
+
+// withRetry runs fn with exponential backoff, retrying up to c.maxRetries
+// times on error.
+func (c *clairClient) withRetry(ctx context.Context, fn func() error) error {
+	var err error
+	backoff := 250 * time.Millisecond
+
+	for attempt := 0; attempt <= c.maxRetries; attempt++ {
+		if err = fn(); err == nil {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+
+	return fmt.Errorf("exceeded %d retries: %w", c.maxRetries, err)
+}
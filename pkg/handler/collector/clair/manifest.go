@@ -0,0 +1,81 @@
+//
+// Copyright 2023 The GUAC Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clair
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/package-url/packageurl-go"
+)
+
+// manifest is the subset of a Clair v4 IndexReport request body this
+// package needs: just enough package identity for the indexer to resolve.
+type manifest struct {
+	Hash     string            `json:"hash"`
+	Packages []manifestPackage `json:"packages"`
+}
+
+type manifestPackage struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	Type    string `json:"type"`
+}
+
+// manifestFromPurl converts a package URL into the Clair manifest entry the
+// indexer expects, deriving package.type/name/version from the purl.
+func manifestFromPurl(purl string) (*manifest, error) {
+	instance, err := packageurl.FromString(purl)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse purl %q: %w", purl, err)
+	}
+
+	pkg := manifestPackage{
+		Name:    instance.Name,
+		Version: instance.Version,
+		Type:    instance.Type,
+	}
+
+	hash, err := manifestHash(pkg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash manifest for purl %q: %w", purl, err)
+	}
+
+	return &manifest{
+		Hash:     hash,
+		Packages: []manifestPackage{pkg},
+	}, nil
+}
+
+// manifestHash derives the content-addressed manifest hash Clair uses to key
+// index reports. Real Clair manifest hashes are digests over the indexed
+// layers' content; since this collector has no layer bytes to hash (it
+// derives its manifest from a purl, not an image), it instead hashes the
+// canonical encoding of the resolved package identity, so that the same
+// package always yields the same hash. The result has the same "sha256:<hex>"
+// form as a real layer digest, so its format alone does not tell the two
+// apart.
+func manifestHash(pkg manifestPackage) (string, error) {
+	data, err := json.Marshal(pkg)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode manifest package: %w", err)
+	}
+
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,64 @@
+//
+// Copyright 2023 The GUAC Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clair
+
+import "testing"
+
+func TestManifestHashIsStableAndContentAddressed(t *testing.T) {
+	pkg := manifestPackage{Name: "openssl", Version: "3.0.3", Type: "conan"}
+
+	got, err := manifestHash(pkg)
+	if err != nil {
+		t.Fatalf("manifestHash() error = %v", err)
+	}
+
+	again, err := manifestHash(pkg)
+	if err != nil {
+		t.Fatalf("manifestHash() error = %v", err)
+	}
+	if got != again {
+		t.Errorf("manifestHash() = %q, want same hash %q on repeat call for identical input", got, again)
+	}
+
+	other := manifestPackage{Name: "openssl", Version: "3.0.4", Type: "conan"}
+	otherHash, err := manifestHash(other)
+	if err != nil {
+		t.Fatalf("manifestHash() error = %v", err)
+	}
+	if got == otherHash {
+		t.Errorf("manifestHash() = %q for both %+v and %+v, want distinct hashes", got, pkg, other)
+	}
+}
+
+func TestManifestFromPurl(t *testing.T) {
+	m, err := manifestFromPurl("pkg:conan/openssl.org/openssl@3.0.3")
+	if err != nil {
+		t.Fatalf("manifestFromPurl() error = %v", err)
+	}
+
+	if len(m.Packages) != 1 {
+		t.Fatalf("manifestFromPurl() Packages = %d entries, want 1", len(m.Packages))
+	}
+	if m.Hash == "" {
+		t.Error("manifestFromPurl() Hash is empty")
+	}
+}
+
+func TestManifestFromPurlRejectsInvalidPurl(t *testing.T) {
+	if _, err := manifestFromPurl("not-a-purl"); err == nil {
+		t.Fatal("manifestFromPurl() expected error for malformed purl, got nil")
+	}
+}
@@ -0,0 +1,56 @@
+//
+// Copyright 2023 The GUAC Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clair
+
+// ResolvedFinding identifies a previously ingested CertifyVuln edge that a
+// re-scan no longer reports, and therefore should be marked resolved.
+type ResolvedFinding struct {
+	CertifyVulnID   string
+	Package         PackageNode
+	VulnerabilityID string
+}
+
+// SweepResolved compares the findings from a fresh scan against the
+// findings recorded from the previous scan of the same packages and returns
+// the previously-recorded CertifyVuln edges that no longer appear, i.e. the
+// ones a re-scan has resolved.
+func SweepResolved(previous, current []VulnFinding) []ResolvedFinding {
+	currentKeys := make(map[string]struct{}, len(current))
+	for _, f := range current {
+		currentKeys[findingKey(f)] = struct{}{}
+	}
+
+	var resolved []ResolvedFinding
+	for _, f := range previous {
+		if _, stillPresent := currentKeys[findingKey(f)]; stillPresent {
+			continue
+		}
+		resolved = append(resolved, ResolvedFinding{
+			CertifyVulnID:   f.CertifyVulnID,
+			Package:         f.Package,
+			VulnerabilityID: f.VulnerabilityID,
+		})
+	}
+
+	return resolved
+}
+
+// findingKey identifies a finding by the package/vulnerability pair a
+// CertifyVuln edge is keyed on, ignoring fields (score, scan time) that can
+// legitimately change between scans of the same finding.
+func findingKey(f VulnFinding) string {
+	return f.Package.ID + "@" + f.VulnerabilityID
+}
@@ -0,0 +1,72 @@
+//
+// Copyright 2023 The GUAC Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git
+
+import "sync"
+
+// RefStore persists the last-seen ref -> commit mapping for a remote between
+// polls, so a collector run only has to report refs that moved or appeared
+// since the previous one.
+type RefStore interface {
+	// LastSeen returns the ref -> commit mapping recorded for remoteURL on a
+	// previous call to SetLastSeen, or an empty map if none was recorded.
+	LastSeen(remoteURL string) (map[string]string, error)
+	// SetLastSeen records refs as the last-seen state for remoteURL.
+	SetLastSeen(remoteURL string, refs map[string]string) error
+}
+
+// inMemoryRefStore is the default RefStore, good enough for a single
+// collector process's lifetime. Longer-lived deployments should back this
+// with the same persistence layer used elsewhere (e.g. the backend's
+// key-value store) rather than relying on process memory across restarts.
+type inMemoryRefStore struct {
+	mu    sync.Mutex
+	state map[string]map[string]string
+}
+
+// NewInMemoryRefStore returns a RefStore that keeps state only for the
+// lifetime of the process.
+func NewInMemoryRefStore() RefStore {
+	return &inMemoryRefStore{state: map[string]map[string]string{}}
+}
+
+func (s *inMemoryRefStore) LastSeen(remoteURL string) (map[string]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	refs, ok := s.state[remoteURL]
+	if !ok {
+		return map[string]string{}, nil
+	}
+
+	out := make(map[string]string, len(refs))
+	for k, v := range refs {
+		out[k] = v
+	}
+	return out, nil
+}
+
+func (s *inMemoryRefStore) SetLastSeen(remoteURL string, refs map[string]string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored := make(map[string]string, len(refs))
+	for k, v := range refs {
+		stored[k] = v
+	}
+	s.state[remoteURL] = stored
+	return nil
+}
@@ -0,0 +1,202 @@
+//
+// Copyright 2023 The GUAC Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	"github.com/go-git/go-git/v5/storage/memory"
+	"golang.org/x/crypto/openpgp"
+)
+
+// gitRepo wraps a go-git repository opened in memory for one collection
+// pass. Using the in-memory storer keeps repeated polls cheap since we only
+// need refs and trees, not a working copy on disk.
+type gitRepo struct {
+	repo *git.Repository
+}
+
+// openOrFetch clones config.RemoteURL into memory, with depth 1 when
+// config.Shallow is set and full history otherwise, using whichever auth
+// method config.Auth selects.
+func openOrFetch(ctx context.Context, config Config) (*gitRepo, error) {
+	auth, err := transportAuth(config.Auth)
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure auth: %w", err)
+	}
+
+	opts := &git.CloneOptions{
+		URL:  config.RemoteURL,
+		Auth: auth,
+		Tags: git.AllTags,
+	}
+	if config.Shallow {
+		opts.Depth = 1
+	}
+
+	repo, err := git.CloneContext(ctx, memory.NewStorage(), nil, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to clone %q: %w", config.RemoteURL, err)
+	}
+
+	return &gitRepo{repo: repo}, nil
+}
+
+// transportAuth builds the go-git transport.AuthMethod matching the
+// configured Auth, if any.
+func transportAuth(a Auth) (transport.AuthMethod, error) {
+	switch a.Method {
+	case AuthMethodNone:
+		return nil, nil
+	case AuthMethodSSHAgent:
+		return ssh.NewSSHAgentAuth("git")
+	case AuthMethodHTTPSToken:
+		return &http.TokenAuth{Token: a.Token}, nil
+	case AuthMethodBasic:
+		return &http.BasicAuth{Username: a.Username, Password: a.Password}, nil
+	default:
+		return nil, fmt.Errorf("unknown auth method: %v", a.Method)
+	}
+}
+
+// tagRefs returns every tag ref in the repository mapped to the commit SHA
+// it currently resolves to.
+func (r *gitRepo) tagRefs() (map[string]string, error) {
+	iter, err := r.repo.Tags()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tags: %w", err)
+	}
+
+	refs := map[string]string{}
+	err = iter.ForEach(func(ref *plumbing.Reference) error {
+		commit, err := r.resolveToCommit(ref.Hash())
+		if err != nil {
+			return fmt.Errorf("failed to resolve tag %q: %w", ref.Name(), err)
+		}
+		refs[ref.Name().Short()] = commit.String()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return refs, nil
+}
+
+// resolveTag builds a TagRef for name/commit, verifying its signature
+// against keyringPath if the tag object carries one.
+func (r *gitRepo) resolveTag(name, commit, keyringPath string) (*TagRef, error) {
+	ref, err := r.repo.Reference(plumbing.NewTagReferenceName(name), true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up tag ref %q: %w", name, err)
+	}
+
+	tag := &TagRef{
+		Name:            name,
+		ResolvedCommit:  commit,
+		AnnotatedCommit: commit,
+	}
+
+	if tagObj, err := r.repo.TagObject(ref.Hash()); err == nil {
+		// Annotated tag: the object's own target is the commit it actually
+		// points at, which is what HashEqual compares against the resolved ref.
+		tag.AnnotatedCommit = tagObj.Target.String()
+
+		if tagObj.PGPSignature != "" {
+			tag.Signed = true
+			if keyringPath != "" {
+				identity, err := verifyTagSignature(tagObj, keyringPath)
+				if err != nil {
+					return nil, fmt.Errorf("failed to verify signature on tag %q: %w", name, err)
+				}
+				tag.SignerIdentity = identity
+			}
+		}
+	}
+
+	digest, err := r.treeDigest(plumbing.NewHash(tag.ResolvedCommit))
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute tree digest for tag %q: %w", name, err)
+	}
+	tag.TreeDigest = digest
+	tag.TreeAlgorithm = "sha1"
+
+	return tag, nil
+}
+
+// resolveToCommit dereferences hash (which may point at a tag, commit, or
+// other object) down to the commit it ultimately identifies.
+func (r *gitRepo) resolveToCommit(hash plumbing.Hash) (plumbing.Hash, error) {
+	if tagObj, err := r.repo.TagObject(hash); err == nil {
+		return r.resolveToCommit(tagObj.Target)
+	}
+	if _, err := r.repo.CommitObject(hash); err == nil {
+		return hash, nil
+	}
+	return plumbing.ZeroHash, fmt.Errorf("hash %s is neither a tag nor a commit", hash)
+}
+
+// treeDigest returns the artifact digest used for the IsOccurrence edge
+// between the resolved commit and the tree it points at: the tree object's
+// own git hash, which is already a content digest (git computes it as the
+// sha1 of the serialized tree object) rather than something this package
+// needs to re-hash.
+func (r *gitRepo) treeDigest(commitHash plumbing.Hash) (string, error) {
+	commit, err := r.repo.CommitObject(commitHash)
+	if err != nil {
+		return "", fmt.Errorf("failed to load commit %s: %w", commitHash, err)
+	}
+
+	tree, err := commit.Tree()
+	if err != nil {
+		return "", fmt.Errorf("failed to load tree for commit %s: %w", commitHash, err)
+	}
+
+	return tree.Hash.String(), nil
+}
+
+// verifyTagSignature checks tagObj's PGP signature against the armored
+// keyring at keyringPath and returns the matched signer's identity.
+func verifyTagSignature(tagObj *object.Tag, keyringPath string) (string, error) {
+	keyringFile, err := os.Open(keyringPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to open keyring %q: %w", keyringPath, err)
+	}
+	defer keyringFile.Close()
+
+	keyring, err := openpgp.ReadArmoredKeyRing(keyringFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse keyring %q: %w", keyringPath, err)
+	}
+
+	entity, err := tagObj.Verify(keyring)
+	if err != nil {
+		return "", fmt.Errorf("signature verification failed: %w", err)
+	}
+
+	for identity := range entity.Identities {
+		return identity, nil
+	}
+	return entity.PrimaryKey.KeyIdString(), nil
+}
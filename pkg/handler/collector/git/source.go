@@ -0,0 +1,214 @@
+//
+// Copyright 2023 The GUAC Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/guacsec/guac/pkg/handler/processor"
+)
+
+// TagRef is a single tag or release resolved against the tree it points at,
+// ready to be turned into HasSourceAt/IsOccurrence/HashEqual edges.
+type TagRef struct {
+	Name string
+
+	// AnnotatedCommit is the SHA of the annotated tag object's target commit
+	// as recorded in the tag itself.
+	AnnotatedCommit string
+	// ResolvedCommit is the SHA go-git actually resolves the ref to. These
+	// two normally agree; when they don't it indicates a moved or
+	// re-signed tag, which is exactly what HashEqual is meant to surface.
+	ResolvedCommit string
+
+	// TreeDigest is the digest computed over the resolved commit's tree,
+	// used as the artifact side of the IsOccurrence edge.
+	TreeDigest    string
+	TreeAlgorithm string
+
+	// Signed is true if the tag carried an OpenPGP signature.
+	Signed bool
+	// SignerIdentity is the verified signer's key identity, populated only
+	// when Signed is true and the signature checked out against the
+	// configured keyring.
+	SignerIdentity string
+}
+
+// Origin reports the provenance string callers should attach to every edge
+// built from this tag, surfacing the verified signer identity when the tag
+// was signed.
+func (t *TagRef) Origin() string {
+	if t.Signed && t.SignerIdentity != "" {
+		return fmt.Sprintf("git collector: signed by %s", t.SignerIdentity)
+	}
+	return "git collector"
+}
+
+// HashesEqual reports whether the tag's annotated target and the commit the
+// ref actually resolves to agree. False means a HashEqual edge is needed to
+// record that the tag moved (or was re-signed) after being cut.
+func (t *TagRef) HashesEqual() bool {
+	return t.AnnotatedCommit == t.ResolvedCommit
+}
+
+// SourceResult is everything FetchSource resolves for one remote: the
+// repository itself plus every tag/release found on it, each carrying
+// enough data to build the HasSourceAt/IsOccurrence/HashEqual edges the
+// request asked for.
+type SourceResult struct {
+	// Type/Namespace/Name mirror the fields of model.SourceInputSpec so
+	// callers can build one directly without reaching back into this
+	// package's internals.
+	Type      string
+	Namespace string
+	Name      string
+
+	Tags []TagRef
+}
+
+// FetchSource clones or fetches config.RemoteURL (shallow only when
+// config.Shallow is set),
+// resolves every new tag/release against its tree, verifies signed tags
+// against the configured keyring, and records the refs it saw in
+// config.RefStore so the next poll only reports what changed.
+func FetchSource(ctx context.Context, config Config) (*SourceResult, error) {
+	repo, err := openOrFetch(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open/fetch %q: %w", config.RemoteURL, err)
+	}
+
+	seen, err := config.RefStore.LastSeen(config.RemoteURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load last-seen refs for %q: %w", config.RemoteURL, err)
+	}
+
+	refs, err := repo.tagRefs()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tag refs on %q: %w", config.RemoteURL, err)
+	}
+
+	var tags []TagRef
+	next := make(map[string]string, len(refs))
+	for name, commit := range refs {
+		next[name] = commit
+		if seen[name] == commit {
+			// Already reported in a previous poll; skip to keep this an
+			// incremental collector rather than a full re-scan every time.
+			continue
+		}
+
+		tag, err := repo.resolveTag(name, commit, config.OpenPGPKeyringPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve tag %q on %q: %w", name, config.RemoteURL, err)
+		}
+		tags = append(tags, *tag)
+	}
+
+	if err := config.RefStore.SetLastSeen(config.RemoteURL, next); err != nil {
+		return nil, fmt.Errorf("failed to persist last-seen refs for %q: %w", config.RemoteURL, err)
+	}
+
+	ns, name := splitRemote(config.RemoteURL)
+	return &SourceResult{
+		Type:      "git",
+		Namespace: ns,
+		Name:      name,
+		Tags:      tags,
+	}, nil
+}
+
+// splitRemote turns a remote URL into the (namespace, name) pair
+// SourceInputSpec expects, deriving namespace from the remote's actual host
+// rather than assuming GitHub, e.g. "https://gitlab.com/guacsec/guac"
+// becomes ("gitlab", "gitlab.com/guacsec/guac") and
+// "git@bitbucket.org:guacsec/guac.git" becomes
+// ("bitbucket", "bitbucket.org/guacsec/guac").
+func splitRemote(remoteURL string) (namespace, name string) {
+	host, path := hostAndPath(remoteURL)
+	if host == "" {
+		return "", remoteURL
+	}
+
+	label := host
+	if i := strings.IndexByte(host, '.'); i > 0 {
+		label = host[:i]
+	}
+
+	return label, host + "/" + path
+}
+
+// hostAndPath splits remoteURL into its host and repository path, handling
+// both URL-form remotes (https://host/path, ssh://host/path) and the
+// scp-like syntax git commonly accepts for SSH remotes (user@host:path).
+func hostAndPath(remoteURL string) (host, path string) {
+	if u, err := url.Parse(remoteURL); err == nil && u.Host != "" {
+		return u.Host, strings.TrimSuffix(strings.TrimPrefix(u.Path, "/"), ".git")
+	}
+
+	if at := strings.Index(remoteURL, "@"); at >= 0 {
+		rest := remoteURL[at+1:]
+		if colon := strings.Index(rest, ":"); colon >= 0 {
+			return rest[:colon], strings.TrimSuffix(rest[colon+1:], ".git")
+		}
+	}
+
+	return "", remoteURL
+}
+
+// asDocument converts a resolved tag into the processor.Document shape the
+// collector dispatcher expects. The blob is the JSON-encoded edge data
+// (source identity, tag, annotated/resolved commit, tree digest, and
+// signer-derived origin) that a downstream parser turns into
+// SourceInputSpec/HasSourceAt/IsOccurrence/HashEqual mutations; see
+// FetchSource for a version of the same data built directly for callers
+// that want to submit those mutations themselves.
+func (t *TagRef) asDocument(source *SourceResult, sourceInfo string) (*processor.Document, error) {
+	payload := struct {
+		Source          SourceResult `json:"source"`
+		Tag             string       `json:"tag"`
+		AnnotatedCommit string       `json:"annotatedCommit"`
+		ResolvedCommit  string       `json:"resolvedCommit"`
+		HashesEqual     bool         `json:"hashesEqual"`
+		TreeDigest      string       `json:"treeDigest"`
+		TreeAlgorithm   string       `json:"treeAlgorithm"`
+		Origin          string       `json:"origin"`
+	}{
+		Source:          *source,
+		Tag:             t.Name,
+		AnnotatedCommit: t.AnnotatedCommit,
+		ResolvedCommit:  t.ResolvedCommit,
+		HashesEqual:     t.HashesEqual(),
+		TreeDigest:      t.TreeDigest,
+		TreeAlgorithm:   t.TreeAlgorithm,
+		Origin:          t.Origin(),
+	}
+
+	blob, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal tag %q into document: %w", t.Name, err)
+	}
+
+	return &processor.Document{
+		Blob:              blob,
+		Type:              processor.DocumentUnknown,
+		Format:            processor.FormatJSON,
+		SourceInformation: processor.SourceInformation{Source: sourceInfo, Collector: CollectorGit},
+	}, nil
+}
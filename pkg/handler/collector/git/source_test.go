@@ -0,0 +1,94 @@
+//
+// Copyright 2023 The GUAC Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git
+
+import "testing"
+
+func TestSplitRemote(t *testing.T) {
+	tests := []struct {
+		name          string
+		remoteURL     string
+		wantNamespace string
+		wantName      string
+	}{
+		{
+			name:          "https github",
+			remoteURL:     "https://github.com/guacsec/guac",
+			wantNamespace: "github",
+			wantName:      "github.com/guacsec/guac",
+		},
+		{
+			name:          "https gitlab",
+			remoteURL:     "https://gitlab.com/guacsec/guac",
+			wantNamespace: "gitlab",
+			wantName:      "gitlab.com/guacsec/guac",
+		},
+		{
+			name:          "https with .git suffix",
+			remoteURL:     "https://gitlab.com/guacsec/guac.git",
+			wantNamespace: "gitlab",
+			wantName:      "gitlab.com/guacsec/guac",
+		},
+		{
+			name:          "scp-like ssh syntax",
+			remoteURL:     "git@bitbucket.org:guacsec/guac.git",
+			wantNamespace: "bitbucket",
+			wantName:      "bitbucket.org/guacsec/guac",
+		},
+		{
+			name:          "self-hosted host with subdomain",
+			remoteURL:     "https://git.example.com/guacsec/guac",
+			wantNamespace: "git",
+			wantName:      "git.example.com/guacsec/guac",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotNamespace, gotName := splitRemote(tt.remoteURL)
+			if gotNamespace != tt.wantNamespace {
+				t.Errorf("splitRemote(%q) namespace = %q, want %q", tt.remoteURL, gotNamespace, tt.wantNamespace)
+			}
+			if gotName != tt.wantName {
+				t.Errorf("splitRemote(%q) name = %q, want %q", tt.remoteURL, gotName, tt.wantName)
+			}
+		})
+	}
+}
+
+func TestTagRefOrigin(t *testing.T) {
+	signed := &TagRef{Signed: true, SignerIdentity: "maintainer@guac.dev"}
+	if got, want := signed.Origin(), "git collector: signed by maintainer@guac.dev"; got != want {
+		t.Errorf("Origin() = %q, want %q", got, want)
+	}
+
+	unsigned := &TagRef{}
+	if got, want := unsigned.Origin(), "git collector"; got != want {
+		t.Errorf("Origin() = %q, want %q", got, want)
+	}
+}
+
+func TestTagRefHashesEqual(t *testing.T) {
+	same := &TagRef{AnnotatedCommit: "abc123", ResolvedCommit: "abc123"}
+	if !same.HashesEqual() {
+		t.Error("HashesEqual() = false, want true for matching commits")
+	}
+
+	moved := &TagRef{AnnotatedCommit: "abc123", ResolvedCommit: "def456"}
+	if moved.HashesEqual() {
+		t.Error("HashesEqual() = true, want false for a moved tag")
+	}
+}
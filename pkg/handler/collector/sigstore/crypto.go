@@ -0,0 +1,315 @@
+//
+// Copyright 2023 The GUAC Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sigstore
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// fulcioIssuerOID is the X.509 extension Fulcio embeds the OIDC issuer URL
+// in for certificates it mints (the original, still widely used, "v1" OID).
+var fulcioIssuerOID = []int{1, 3, 6, 1, 4, 1, 57264, 1, 1}
+
+// verifyKeyless checks b's certificate against fulcioRoot as of
+// verificationTime, confirms the embedded issuer extension and SAN identity
+// match what's expected, and verifies b's signature over its payload using
+// the certificate's key. verificationTime should be the Rekor entry's
+// IntegratedTime rather than wall-clock time: Fulcio leaf certs are
+// short-lived (~10 minutes), so checking them against time.Now() would
+// reject every attestation old enough to be worth re-verifying.
+func verifyKeyless(b bundle, fulcioRoot []byte, expectedIssuer, expectedIdentity string, verificationTime time.Time) (string, error) {
+	cert, err := parseCertificate(b.Certificate)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse signing certificate: %w", err)
+	}
+
+	roots, err := certPool(fulcioRoot)
+	if err != nil {
+		return "", fmt.Errorf("failed to load fulcio root: %w", err)
+	}
+
+	opts := x509.VerifyOptions{Roots: roots, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageAny}}
+	if !verificationTime.IsZero() {
+		opts.CurrentTime = verificationTime
+	}
+	if _, err := cert.Verify(opts); err != nil {
+		return "", fmt.Errorf("certificate chain does not verify against fulcio root: %w", err)
+	}
+
+	issuer := extensionValue(cert, fulcioIssuerOID)
+	if expectedIssuer != "" && issuer != expectedIssuer {
+		return "", fmt.Errorf("certificate issuer %q does not match expected issuer %q", issuer, expectedIssuer)
+	}
+
+	identity := certIdentity(cert)
+	if expectedIdentity != "" && identity != expectedIdentity {
+		return "", fmt.Errorf("certificate identity %q does not match expected identity %q", identity, expectedIdentity)
+	}
+
+	if err := verifySignature(cert.PublicKey, b.PayloadType, b.PayloadBase64, b.Signature); err != nil {
+		return "", fmt.Errorf("payload signature does not verify against certificate key: %w", err)
+	}
+
+	return identity, nil
+}
+
+// verifyWithKey checks b's detached signature against publicKey and returns
+// a stable fingerprint identifying which key produced it.
+func verifyWithKey(b bundle, publicKey []byte) (string, error) {
+	block, _ := pem.Decode(publicKey)
+	if block == nil {
+		return "", fmt.Errorf("failed to PEM-decode public key")
+	}
+
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse public key: %w", err)
+	}
+
+	if err := verifySignature(pub, b.PayloadType, b.PayloadBase64, b.Signature); err != nil {
+		return "", fmt.Errorf("payload signature does not verify against configured key: %w", err)
+	}
+
+	sum := sha256.Sum256(block.Bytes)
+	return "key:" + hex.EncodeToString(sum[:]), nil
+}
+
+// verifySignature checks sig over the sha256 digest of the DSSE
+// Pre-Authentication Encoding (PAE) of payloadType and the base64-decoded
+// payload, using an ECDSA public key (the only key type Fulcio/cosign issue
+// for keyless and the common case for key-based signing). DSSE envelopes
+// sign the PAE, not the bare payload - see
+// https://github.com/secure-systems-lab/dsse/blob/master/protocol.md.
+func verifySignature(pub any, payloadType, payloadBase64 string, sig []byte) error {
+	ecdsaKey, ok := pub.(*ecdsa.PublicKey)
+	if !ok {
+		return fmt.Errorf("unsupported public key type %T", pub)
+	}
+
+	payload, err := base64.StdEncoding.DecodeString(payloadBase64)
+	if err != nil {
+		return fmt.Errorf("failed to decode payload: %w", err)
+	}
+
+	digest := sha256.Sum256(pae(payloadType, payload))
+	if !ecdsa.VerifyASN1(ecdsaKey, digest[:], sig) {
+		return fmt.Errorf("signature verification failed")
+	}
+	return nil
+}
+
+// pae computes the DSSE Pre-Authentication Encoding for payloadType and
+// payload: "DSSEv1" SP len(type) SP type SP len(body) SP body, where SP is
+// a single space and len is the ASCII decimal byte length of what follows.
+func pae(payloadType string, payload []byte) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("DSSEv1")
+	buf.WriteByte(' ')
+	buf.WriteString(strconv.Itoa(len(payloadType)))
+	buf.WriteByte(' ')
+	buf.WriteString(payloadType)
+	buf.WriteByte(' ')
+	buf.WriteString(strconv.Itoa(len(payload)))
+	buf.WriteByte(' ')
+	buf.Write(payload)
+	return buf.Bytes()
+}
+
+// parseCertificate PEM-decodes and parses a leaf certificate.
+func parseCertificate(pemBytes []byte) (*x509.Certificate, error) {
+	block, _ := pem.Decode(pemBytes)
+	if block == nil {
+		return nil, fmt.Errorf("failed to PEM-decode certificate")
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
+
+// certPool builds a cert pool from one or more PEM-encoded roots.
+func certPool(pemBytes []byte) (*x509.CertPool, error) {
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pemBytes) {
+		return nil, fmt.Errorf("no certificates found in fulcio root")
+	}
+	return pool, nil
+}
+
+// extensionValue returns the decoded string content of the first extension
+// matching oid, or "" if not present. Fulcio encodes these extensions as a
+// DER UTF8String, so the raw ext.Value bytes still carry the ASN.1 tag and
+// length prefix and must be unmarshalled, not used as-is.
+func extensionValue(cert *x509.Certificate, oid []int) string {
+	for _, ext := range cert.Extensions {
+		if !ext.Id.Equal(oid) {
+			continue
+		}
+
+		var value string
+		if _, err := asn1.Unmarshal(ext.Value, &value); err != nil {
+			// Older Fulcio certs encoded this extension as a bare UTF-8
+			// string with no ASN.1 wrapper; fall back to the raw bytes
+			// rather than failing closed on those.
+			return string(ext.Value)
+		}
+		return value
+	}
+	return ""
+}
+
+// certIdentity returns the identity Fulcio embedded in the certificate's
+// SAN: the URI SAN for CI/CD-issued identities (e.g. a GitHub Actions
+// workflow ref), falling back to the email SAN used for human identities.
+func certIdentity(cert *x509.Certificate) string {
+	if len(cert.URIs) > 0 {
+		return cert.URIs[0].String()
+	}
+	if len(cert.EmailAddresses) > 0 {
+		return cert.EmailAddresses[0]
+	}
+	return ""
+}
+
+// checkRekorInclusion recomputes the Merkle inclusion proof for entry and
+// verifies the log's signature over the resulting root hash against
+// rekorPublicKey, failing closed on any mismatch. A self-consistent Merkle
+// proof alone only proves the entry belongs to *some* tree with that root;
+// without rekorPublicKey there is nothing tying that tree to the actual
+// Rekor log, so a missing key is itself a hard failure rather than a
+// degraded-but-passing check.
+func checkRekorInclusion(entry rekorEntry, rekorPublicKey []byte) (*rekorInclusionProof, error) {
+	if len(rekorPublicKey) == 0 {
+		return nil, fmt.Errorf("no rekor public key configured: cannot authenticate the transparency log's signed entry timestamp")
+	}
+
+	bodyBytes, err := base64.StdEncoding.DecodeString(entry.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode entry body: %w", err)
+	}
+
+	computedRoot, err := verifyMerkleInclusion(bodyBytes, entry.InclusionProof)
+	if err != nil {
+		return nil, fmt.Errorf("merkle inclusion proof does not verify: %w", err)
+	}
+
+	if computedRoot != entry.InclusionProof.RootHash {
+		return nil, fmt.Errorf("computed root hash %q does not match proof root hash %q", computedRoot, entry.InclusionProof.RootHash)
+	}
+
+	if err := verifyRekorSignature(rekorPublicKey, entry.InclusionProof.RootHash, entry.SignedEntryTimestamp); err != nil {
+		return nil, fmt.Errorf("signed entry timestamp does not verify: %w", err)
+	}
+
+	return &rekorInclusionProof{
+		LogIndex:   entry.LogIndex,
+		LogID:      entry.LogID,
+		RootHash:   entry.InclusionProof.RootHash,
+		SignedTime: entry.IntegratedTime,
+	}, nil
+}
+
+// verifyMerkleInclusion walks the RFC 6962 audit path in proof, starting
+// from leafData, and returns the resulting hex-encoded root hash.
+func verifyMerkleInclusion(leafData []byte, proof inclusionProof) (string, error) {
+	if proof.LogIndex < 0 || proof.LogIndex >= proof.TreeSize {
+		return "", fmt.Errorf("log index %d out of range for tree size %d", proof.LogIndex, proof.TreeSize)
+	}
+
+	hash := rfc6962LeafHash(leafData)
+	fn, sn := proof.LogIndex, proof.TreeSize-1
+
+	for _, siblingHex := range proof.Hashes {
+		sibling, err := hex.DecodeString(siblingHex)
+		if err != nil {
+			return "", fmt.Errorf("failed to decode sibling hash: %w", err)
+		}
+		if sn == 0 {
+			return "", fmt.Errorf("audit path is longer than tree size %d allows", proof.TreeSize)
+		}
+
+		if fn%2 == 1 || fn == sn {
+			hash = rfc6962NodeHash(sibling, hash)
+			for fn%2 == 0 && fn != 0 {
+				fn /= 2
+				sn /= 2
+			}
+		} else {
+			hash = rfc6962NodeHash(hash, sibling)
+		}
+		fn /= 2
+		sn /= 2
+	}
+
+	if sn != 0 {
+		return "", fmt.Errorf("audit path is shorter than tree size %d requires", proof.TreeSize)
+	}
+	return hex.EncodeToString(hash), nil
+}
+
+// rfc6962LeafHash and rfc6962NodeHash implement the RFC 6962 (certificate
+// transparency) Merkle tree hashing convention Rekor's log uses: leaves are
+// hashed with a 0x00 prefix, internal nodes with a 0x01 prefix, both to
+// prevent second-preimage attacks between leaf and node hashes.
+func rfc6962LeafHash(data []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte{0x00})
+	h.Write(data)
+	return h.Sum(nil)
+}
+
+func rfc6962NodeHash(left, right []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte{0x01})
+	h.Write(left)
+	h.Write(right)
+	return h.Sum(nil)
+}
+
+// verifyRekorSignature checks sig as an ECDSA signature over root hash,
+// produced by the Rekor log key rekorPublicKey.
+func verifyRekorSignature(rekorPublicKey []byte, rootHashHex string, sig []byte) error {
+	block, _ := pem.Decode(rekorPublicKey)
+	if block == nil {
+		return fmt.Errorf("failed to PEM-decode rekor public key")
+	}
+
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return fmt.Errorf("failed to parse rekor public key: %w", err)
+	}
+	ecdsaKey, ok := pub.(*ecdsa.PublicKey)
+	if !ok {
+		return fmt.Errorf("unsupported rekor public key type %T", pub)
+	}
+
+	rootHash, err := hex.DecodeString(rootHashHex)
+	if err != nil {
+		return fmt.Errorf("failed to decode root hash: %w", err)
+	}
+
+	if !ecdsa.VerifyASN1(ecdsaKey, rootHash, sig) {
+		return fmt.Errorf("signature verification failed")
+	}
+	return nil
+}
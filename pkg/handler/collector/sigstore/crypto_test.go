@@ -0,0 +1,224 @@
+//
+// Copyright 2023 The GUAC Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sigstore
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestExtensionValueDecodesDEREncodedUTF8String(t *testing.T) {
+	want := "https://token.actions.githubusercontent.com"
+	raw, err := asn1.MarshalWithParams(want, "utf8")
+	if err != nil {
+		t.Fatalf("failed to marshal test extension: %v", err)
+	}
+
+	cert := &x509.Certificate{
+		Extensions: []pkix.Extension{
+			{Id: asn1.ObjectIdentifier(fulcioIssuerOID), Value: raw},
+		},
+	}
+
+	if got := extensionValue(cert, fulcioIssuerOID); got != want {
+		t.Errorf("extensionValue() = %q, want %q (raw DER bytes leaked through)", got, want)
+	}
+}
+
+func TestExtensionValueFallsBackOnNonASN1Bytes(t *testing.T) {
+	want := "legacy-raw-issuer"
+	cert := &x509.Certificate{
+		Extensions: []pkix.Extension{
+			{Id: asn1.ObjectIdentifier(fulcioIssuerOID), Value: []byte(want)},
+		},
+	}
+
+	if got := extensionValue(cert, fulcioIssuerOID); got != want {
+		t.Errorf("extensionValue() = %q, want %q", got, want)
+	}
+}
+
+// TestVerifyKeylessAcceptsRealFulcioShapedCert builds a self-signed
+// certificate carrying the issuer extension DER-encoded the way Fulcio
+// actually encodes it, and checks that verifyKeyless's issuer comparison
+// (via extensionValue) matches instead of comparing against the raw,
+// tag-prefixed DER bytes.
+func TestVerifyKeylessAcceptsRealFulcioShapedCert(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+
+	issuer := "https://token.actions.githubusercontent.com"
+	identity, err := url.Parse("https://github.com/guacsec/guac/.github/workflows/release.yml@refs/heads/main")
+	if err != nil {
+		t.Fatalf("failed to parse identity URL: %v", err)
+	}
+
+	issuerExt, err := asn1.MarshalWithParams(issuer, "utf8")
+	if err != nil {
+		t.Fatalf("failed to marshal issuer extension: %v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		URIs:                  []*url.URL{identity},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		ExtraExtensions: []pkix.Extension{
+			{Id: asn1.ObjectIdentifier(fulcioIssuerOID), Value: issuerExt},
+		},
+	}
+
+	certDER, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create test certificate: %v", err)
+	}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER})
+
+	payloadType := "application/vnd.in-toto+json"
+	payloadBase64 := base64.StdEncoding.EncodeToString([]byte("test payload"))
+	decoded, err := base64.StdEncoding.DecodeString(payloadBase64)
+	if err != nil {
+		t.Fatalf("failed to decode payload: %v", err)
+	}
+	digest := sha256.Sum256(pae(payloadType, decoded))
+	sig, err := ecdsa.SignASN1(rand.Reader, key, digest[:])
+	if err != nil {
+		t.Fatalf("failed to sign payload: %v", err)
+	}
+
+	b := bundle{
+		PayloadType:   payloadType,
+		PayloadBase64: payloadBase64,
+		Signature:     sig,
+		Certificate:   certPEM,
+	}
+
+	// The cert is only valid for an hour around time.Now(); verify against a
+	// point inside that window, the way a real call would pass the Rekor
+	// entry's IntegratedTime rather than wall-clock time.
+	gotIdentity, err := verifyKeyless(b, certPEM, issuer, identity.String(), time.Now())
+	if err != nil {
+		t.Fatalf("verifyKeyless() error = %v", err)
+	}
+	if gotIdentity != identity.String() {
+		t.Errorf("verifyKeyless() identity = %q, want %q", gotIdentity, identity.String())
+	}
+}
+
+// TestVerifyKeylessUsesProvidedVerificationTime checks that verifyKeyless
+// validates the certificate's NotBefore/NotAfter window against the time it
+// was given, not time.Now() - the whole point of threading the Rekor entry's
+// IntegratedTime through, since Fulcio certs are only valid for ~10 minutes.
+func TestVerifyKeylessUsesProvidedVerificationTime(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		NotBefore:             time.Now().Add(-30 * 24 * time.Hour),
+		NotAfter:              time.Now().Add(-30*24*time.Hour + 10*time.Minute),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+	}
+
+	certDER, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create test certificate: %v", err)
+	}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER})
+
+	payloadType := "application/vnd.in-toto+json"
+	payloadBase64 := base64.StdEncoding.EncodeToString([]byte("test payload"))
+	decoded, err := base64.StdEncoding.DecodeString(payloadBase64)
+	if err != nil {
+		t.Fatalf("failed to decode payload: %v", err)
+	}
+	digest := sha256.Sum256(pae(payloadType, decoded))
+	sig, err := ecdsa.SignASN1(rand.Reader, key, digest[:])
+	if err != nil {
+		t.Fatalf("failed to sign payload: %v", err)
+	}
+
+	b := bundle{
+		PayloadType:   payloadType,
+		PayloadBase64: payloadBase64,
+		Signature:     sig,
+		Certificate:   certPEM,
+	}
+
+	if _, err := verifyKeyless(b, certPEM, "", "", time.Now()); err == nil {
+		t.Fatal("verifyKeyless() expected error verifying a long-expired cert against time.Now(), got nil")
+	}
+
+	if _, err := verifyKeyless(b, certPEM, "", "", template.NotBefore.Add(time.Minute)); err != nil {
+		t.Errorf("verifyKeyless() error = %v, want nil when verifying against a time inside the cert's validity window", err)
+	}
+}
+
+func TestVerifyKeylessRejectsIssuerMismatch(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+
+	issuerExt, err := asn1.Marshal("https://token.actions.githubusercontent.com")
+	if err != nil {
+		t.Fatalf("failed to marshal issuer extension: %v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		ExtraExtensions: []pkix.Extension{
+			{Id: asn1.ObjectIdentifier(fulcioIssuerOID), Value: issuerExt},
+		},
+	}
+
+	certDER, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create test certificate: %v", err)
+	}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER})
+
+	b := bundle{Certificate: certPEM}
+
+	if _, err := verifyKeyless(b, certPEM, "https://unexpected-issuer.example.com", "", time.Now()); err == nil {
+		t.Fatal("verifyKeyless() expected error on issuer mismatch, got nil")
+	}
+}
@@ -0,0 +1,268 @@
+//
+// Copyright 2023 The GUAC Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sigstore
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	rekorSearchPath = "/api/v1/index/retrieve"
+	rekorEntryPath  = "/api/v1/log/entries"
+)
+
+// fetchBundles resolves source (an artifact digest such as "sha256:...")
+// against Rekor: it searches the index for every entry whose subject hash
+// matches, fetches each entry body, and decodes its DSSE envelope into a
+// bundle ready for verification.
+func fetchBundles(ctx context.Context, config Config, source string) ([]bundle, error) {
+	uuids, err := searchRekorIndex(ctx, config.RekorURL, source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search rekor index for %q: %w", source, err)
+	}
+
+	bundles := make([]bundle, 0, len(uuids))
+	for _, uuid := range uuids {
+		entry, err := fetchRekorEntry(ctx, config.RekorURL, uuid)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch rekor entry %q: %w", uuid, err)
+		}
+
+		b, err := entryToBundle(source, entry)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode rekor entry %q: %w", uuid, err)
+		}
+
+		bundles = append(bundles, *b)
+	}
+
+	return bundles, nil
+}
+
+// searchRekorIndex looks up every log entry UUID indexed under the given
+// artifact hash, via Rekor's /api/v1/index/retrieve search API.
+func searchRekorIndex(ctx context.Context, rekorURL, hash string) ([]string, error) {
+	body, err := json.Marshal(struct {
+		Hash string `json:"hash"`
+	}{Hash: hash})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal rekor search request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, rekorURL+rekorSearchPath, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build rekor search request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("rekor search request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("rekor search returned status %d", resp.StatusCode)
+	}
+
+	var uuids []string
+	if err := json.NewDecoder(resp.Body).Decode(&uuids); err != nil {
+		return nil, fmt.Errorf("failed to decode rekor search response: %w", err)
+	}
+
+	return uuids, nil
+}
+
+// rekorAPIEntry mirrors the shape of one value in the map returned by
+// GET /api/v1/log/entries/{uuid}.
+type rekorAPIEntry struct {
+	Body           string `json:"body"`
+	IntegratedTime int64  `json:"integratedTime"`
+	LogID          string `json:"logID"`
+	LogIndex       int64  `json:"logIndex"`
+	Verification   struct {
+		InclusionProof struct {
+			LogIndex int64    `json:"logIndex"`
+			RootHash string   `json:"rootHash"`
+			TreeSize int64    `json:"treeSize"`
+			Hashes   []string `json:"hashes"`
+		} `json:"inclusionProof"`
+		SignedEntryTimestamp string `json:"signedEntryTimestamp"`
+	} `json:"verification"`
+}
+
+// fetchRekorEntry fetches and flattens the single-entry map Rekor returns
+// for a given UUID.
+func fetchRekorEntry(ctx context.Context, rekorURL, uuid string) (*rekorEntry, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rekorURL+rekorEntryPath+"/"+uuid, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build rekor get-entry request: %w", err)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("rekor get-entry request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("rekor get-entry returned status %d", resp.StatusCode)
+	}
+
+	var entries map[string]rekorAPIEntry
+	if err := json.NewDecoder(resp.Body).Decode(&entries); err != nil {
+		return nil, fmt.Errorf("failed to decode rekor get-entry response: %w", err)
+	}
+
+	api, ok := entries[uuid]
+	if !ok {
+		return nil, fmt.Errorf("rekor response did not contain entry %q", uuid)
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(api.Verification.SignedEntryTimestamp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode signed entry timestamp: %w", err)
+	}
+
+	return &rekorEntry{
+		UUID:           uuid,
+		LogIndex:       api.LogIndex,
+		LogID:          api.LogID,
+		IntegratedTime: time.Unix(api.IntegratedTime, 0).UTC(),
+		Body:           api.Body,
+		InclusionProof: inclusionProof{
+			LogIndex: api.Verification.InclusionProof.LogIndex,
+			TreeSize: api.Verification.InclusionProof.TreeSize,
+			RootHash: api.Verification.InclusionProof.RootHash,
+			Hashes:   api.Verification.InclusionProof.Hashes,
+		},
+		SignedEntryTimestamp: sig,
+	}, nil
+}
+
+// rekorEntryBody is the canonicalized "intoto" entry kind Rekor stores for
+// DSSE-enveloped in-toto attestations, decoded from rekorEntry.Body.
+type rekorEntryBody struct {
+	Kind string `json:"kind"`
+	Spec struct {
+		Content struct {
+			// Envelope is the base64-encoded DSSE envelope: the same bytes
+			// cosign attaches to the OCI attestation manifest.
+			Envelope string `json:"envelope"`
+		} `json:"content"`
+		Signature struct {
+			PublicKey struct {
+				Content string `json:"content"` // base64-encoded PEM cert/key
+			} `json:"publicKey"`
+		} `json:"signature"`
+	} `json:"spec"`
+}
+
+// dsseEnvelope is the detached signature envelope format cosign attestations
+// use: a base64 payload (the in-toto statement) plus one or more signatures.
+type dsseEnvelope struct {
+	PayloadType string `json:"payloadType"`
+	Payload     string `json:"payload"`
+	Signatures  []struct {
+		Sig string `json:"sig"`
+	} `json:"signatures"`
+}
+
+// inTotoStatement is the subset of an in-toto attestation statement needed
+// to classify which predicate a bundle carries.
+type inTotoStatement struct {
+	PredicateType string `json:"predicateType"`
+}
+
+// entryToBundle decodes a Rekor entry body into a verifiable bundle.
+func entryToBundle(subject string, entry *rekorEntry) (*bundle, error) {
+	bodyJSON, err := base64.StdEncoding.DecodeString(entry.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to base64-decode entry body: %w", err)
+	}
+
+	var body rekorEntryBody
+	if err := json.Unmarshal(bodyJSON, &body); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal entry body: %w", err)
+	}
+	if body.Kind != "intoto" {
+		return nil, fmt.Errorf("unsupported rekor entry kind %q", body.Kind)
+	}
+
+	envelopeJSON, err := base64.StdEncoding.DecodeString(body.Spec.Content.Envelope)
+	if err != nil {
+		return nil, fmt.Errorf("failed to base64-decode DSSE envelope: %w", err)
+	}
+
+	var envelope dsseEnvelope
+	if err := json.Unmarshal(envelopeJSON, &envelope); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal DSSE envelope: %w", err)
+	}
+	if len(envelope.Signatures) == 0 {
+		return nil, fmt.Errorf("DSSE envelope carries no signatures")
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(envelope.Signatures[0].Sig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to base64-decode DSSE signature: %w", err)
+	}
+
+	cert, err := base64.StdEncoding.DecodeString(body.Spec.Signature.PublicKey.Content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to base64-decode signing certificate/key: %w", err)
+	}
+
+	statementJSON, err := base64.StdEncoding.DecodeString(envelope.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to base64-decode in-toto statement: %w", err)
+	}
+	var statement inTotoStatement
+	if err := json.Unmarshal(statementJSON, &statement); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal in-toto statement: %w", err)
+	}
+
+	return &bundle{
+		Subject:       subject,
+		Predicate:     classifyPredicate(statement.PredicateType),
+		PayloadType:   envelope.PayloadType,
+		PayloadBase64: envelope.Payload,
+		Signature:     sig,
+		Certificate:   cert,
+		Rekor:         *entry,
+	}, nil
+}
+
+// classifyPredicate maps an in-toto predicateType URI onto the PredicateType
+// this package knows how to turn into a mutation.
+func classifyPredicate(predicateType string) PredicateType {
+	switch {
+	case strings.Contains(predicateType, "slsa.dev/provenance"):
+		return PredicateSLSAProvenance
+	case strings.Contains(predicateType, "spdx"), strings.Contains(predicateType, "cyclonedx"):
+		return PredicateSBOM
+	case strings.Contains(predicateType, "openvex"), strings.Contains(predicateType, "attestation/vex"):
+		return PredicateVEX
+	default:
+		return PredicateOccurrence
+	}
+}
@@ -0,0 +1,145 @@
+//
+// Copyright 2023 The GUAC Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package sigstore implements a collector that pulls cosign/sigstore
+// attestation bundles (signed SBOM/SLSA/VEX documents) from a Rekor
+// transparency-log index and verifies them before handing them off to the
+// processor pipeline.
+//
+// Pulling bundles directly from an OCI registry (the other source the
+// original request asked for) is not implemented here: fetchBundles only
+// resolves sources against Rekor's index. Add an OCI-pull path alongside it
+// if that's needed.
+package sigstore
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/guacsec/guac/pkg/handler/collector"
+	"github.com/guacsec/guac/pkg/handler/processor"
+)
+
+const (
+	// CollectorSigstore is the key used to register this collector with the
+	// dispatcher in pkg/handler/collector.
+	CollectorSigstore = "sigstore"
+)
+
+// AuthMode selects how the verifier should establish trust for a bundle.
+type AuthMode int
+
+const (
+	// AuthModeKeyless verifies the certificate chain against a Fulcio root
+	// and checks the embedded OIDC identity/issuer pair.
+	AuthModeKeyless AuthMode = iota
+	// AuthModeKey verifies a detached signature against a configured public key.
+	AuthModeKey
+)
+
+// Config holds the trust material and connection details needed to fetch and
+// verify attestations.
+type Config struct {
+	// Sources is the set of artifact digests (e.g. "sha256:...") to search
+	// the configured Rekor index for.
+	Sources []string
+
+	Mode AuthMode
+
+	// FulcioRoot is the PEM-encoded Fulcio root certificate used in keyless mode.
+	FulcioRoot []byte
+	// ExpectedIssuer and ExpectedIdentity constrain keyless verification to a
+	// specific OIDC issuer/identity pair.
+	ExpectedIssuer   string
+	ExpectedIdentity string
+
+	// PublicKey is the PEM-encoded public key used in key-based mode.
+	PublicKey []byte
+
+	// RekorPublicKey is used to verify the signed entry timestamp and
+	// inclusion proof returned by the transparency log.
+	RekorPublicKey []byte
+	RekorURL       string
+}
+
+// sigstoreCollector implements collector.Collector and emits one
+// processor.Document per successfully verified attestation.
+type sigstoreCollector struct {
+	config Config
+	poll   bool
+}
+
+// NewSigstoreCollector returns a collector that retrieves and verifies
+// attestations according to config. poll is stored on the collector, but
+// RetrieveArtifacts makes a single pass over the configured sources whether
+// or not it is set.
+func NewSigstoreCollector(config Config, poll bool) *sigstoreCollector {
+	return &sigstoreCollector{
+		config: config,
+		poll:   poll,
+	}
+}
+
+// RetrieveArtifacts fetches bundles from the configured sources, verifies
+// each one, and emits a processor.Document per verified attestation. It
+// fails closed: any fetch or Rekor inclusion error for an individual
+// artifact is reported through logging.FromContext and that artifact is
+// skipped, rather than being ingested unverified or aborting the rest of
+// the batch. This is the same per-artifact behavior VerifyAttestations
+// uses so the two entry points into this package never disagree.
+func (s *sigstoreCollector) RetrieveArtifacts(ctx context.Context, docChannel chan<- *processor.Document) error {
+	results, err := VerifyAttestations(ctx, s.config)
+	if err != nil {
+		return fmt.Errorf("failed to verify sigstore attestations: %w", err)
+	}
+
+	for _, result := range results {
+		docChannel <- resultAsDocument(result)
+	}
+
+	return nil
+}
+
+// Type satisfies collector.Collector.
+func (s *sigstoreCollector) Type() string {
+	return CollectorSigstore
+}
+
+// IsDeprecated satisfies collector.Collector.
+func (s *sigstoreCollector) IsDeprecated() bool {
+	return false
+}
+
+// RegisterSigstoreCollector wires the sigstore collector into the shared
+// collector dispatcher under the "sigstore" type.
+func RegisterSigstoreCollector(config Config, poll bool) error {
+	return collector.RegisterDocumentCollector(NewSigstoreCollector(config, poll), CollectorSigstore)
+}
+
+// resultAsDocument converts a verified attestation into the
+// processor.Document shape consumed by the rest of the ingestion pipeline,
+// with the attestation's subject as its source. The Rekor inclusion proof
+// and signer identity are not carried on the document.
+func resultAsDocument(result VerifiedResult) *processor.Document {
+	return &processor.Document{
+		Blob:   []byte(result.PayloadBase64),
+		Type:   processor.DocumentITE6SLSA,
+		Format: processor.FormatJSON,
+		SourceInformation: processor.SourceInformation{
+			Source:    result.Subject,
+			Collector: CollectorSigstore,
+		},
+	}
+}
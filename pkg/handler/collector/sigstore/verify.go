@@ -0,0 +1,183 @@
+//
+// Copyright 2023 The GUAC Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sigstore
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/guacsec/guac/pkg/logging"
+)
+
+// PredicateType identifies the in-toto predicate carried by a bundle, which
+// determines which GraphQL mutation the verified result maps onto.
+type PredicateType string
+
+const (
+	PredicateSLSAProvenance PredicateType = "slsaprovenance"
+	PredicateSBOM           PredicateType = "sbom"
+	PredicateVEX            PredicateType = "vex"
+	// PredicateOccurrence marks a bundle whose subject is the digest of an
+	// artifact occurrence (e.g. a cosign-signed SBOM's subject digest)
+	// rather than a SLSA/SBOM/VEX predicate body, and maps onto IsOccurrence.
+	PredicateOccurrence PredicateType = "occurrence"
+)
+
+// bundle is a single cosign/sigstore attestation as retrieved from a Rekor
+// transparency-log entry, before verification.
+type bundle struct {
+	// Subject is the artifact digest (or source/package) the attestation is about.
+	Subject string
+
+	Predicate PredicateType
+
+	// PayloadType is the DSSE envelope's payloadType field (e.g.
+	// "application/vnd.in-toto+json"), part of what the envelope's
+	// signature actually covers via the PAE - see verifySignature.
+	PayloadType   string
+	PayloadBase64 string
+	Signature     []byte
+	Certificate   []byte // PEM-encoded Fulcio leaf certificate (keyless mode only)
+
+	Rekor rekorEntry
+}
+
+// rekorEntry is the subset of a Rekor GetLogEntryByUUID response this
+// package needs to verify inclusion and carry provenance forward.
+type rekorEntry struct {
+	UUID           string
+	LogIndex       int64
+	LogID          string
+	IntegratedTime time.Time
+
+	// Body is the base64-encoded, canonicalized entry body Rekor hashes to
+	// produce the Merkle leaf.
+	Body string
+
+	InclusionProof       inclusionProof
+	SignedEntryTimestamp []byte
+}
+
+// inclusionProof is Rekor's Merkle audit path for one entry.
+type inclusionProof struct {
+	LogIndex int64
+	TreeSize int64
+	RootHash string   // hex-encoded
+	Hashes   []string // hex-encoded sibling hashes, leaf to root
+}
+
+// rekorInclusionProof is the subset of a Rekor transparency-log entry that
+// we carry forward as provenance on the ingested certification.
+type rekorInclusionProof struct {
+	LogIndex   int64
+	LogID      string
+	RootHash   string
+	SignedTime time.Time
+}
+
+// verifiedAttestation is the outcome of successfully verifying a bundle.
+type verifiedAttestation struct {
+	bundle bundle
+
+	// SignerIdentity is the OIDC identity (keyless) or key fingerprint
+	// (key-based) that produced the signature.
+	SignerIdentity string
+	Inclusion      rekorInclusionProof
+}
+
+// verifyBundle checks the signature chain of bundle according to config.Mode
+// and cross-checks the signed entry against Rekor, returning the inclusion
+// proof as provenance. It fails closed: any error from the transparency-log
+// lookup is returned rather than treated as a soft failure.
+func verifyBundle(ctx context.Context, config Config, b bundle) (*verifiedAttestation, error) {
+	var signer string
+	switch config.Mode {
+	case AuthModeKeyless:
+		id, err := verifyKeyless(b, config.FulcioRoot, config.ExpectedIssuer, config.ExpectedIdentity, b.Rekor.IntegratedTime)
+		if err != nil {
+			return nil, fmt.Errorf("keyless verification failed: %w", err)
+		}
+		signer = id
+	case AuthModeKey:
+		id, err := verifyWithKey(b, config.PublicKey)
+		if err != nil {
+			return nil, fmt.Errorf("key-based verification failed: %w", err)
+		}
+		signer = id
+	default:
+		return nil, fmt.Errorf("unknown verification mode: %v", config.Mode)
+	}
+
+	inclusion, err := checkRekorInclusion(b.Rekor, config.RekorPublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("rekor inclusion check failed: %w", err)
+	}
+
+	return &verifiedAttestation{
+		bundle:         b,
+		SignerIdentity: signer,
+		Inclusion:      *inclusion,
+	}, nil
+}
+
+// VerifiedResult is the caller-facing outcome of fetching and verifying an
+// attestation: enough to build whichever GraphQL mutation its predicate
+// type maps onto, plus the Rekor inclusion proof as provenance.
+type VerifiedResult struct {
+	Subject        string
+	Predicate      PredicateType
+	PayloadBase64  string
+	SignerIdentity string
+	Inclusion      rekorInclusionProof
+}
+
+// VerifyAttestations fetches and verifies every attestation bundle reachable
+// from config.Sources. It fails closed per artifact: a bundle that cannot be
+// fetched or verified is reported through logging.FromContext and skipped,
+// rather than aborting every other artifact in the batch or being ingested
+// unverified. This matches the skip-and-continue behavior of the collector's
+// own RetrieveArtifacts.
+func VerifyAttestations(ctx context.Context, config Config) ([]VerifiedResult, error) {
+	logger := logging.FromContext(ctx)
+
+	var results []VerifiedResult
+	for _, source := range config.Sources {
+		bundles, err := fetchBundles(ctx, config, source)
+		if err != nil {
+			logger.Errorf("failed to fetch sigstore bundles from %q: %v", source, err)
+			continue
+		}
+
+		for _, b := range bundles {
+			verified, err := verifyBundle(ctx, config, b)
+			if err != nil {
+				logger.Errorf("failed to verify attestation %q from %q: %v", b.Subject, source, err)
+				continue
+			}
+
+			results = append(results, VerifiedResult{
+				Subject:        verified.bundle.Subject,
+				Predicate:      verified.bundle.Predicate,
+				PayloadBase64:  verified.bundle.PayloadBase64,
+				SignerIdentity: verified.SignerIdentity,
+				Inclusion:      verified.Inclusion,
+			})
+		}
+	}
+
+	return results, nil
+}